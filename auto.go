@@ -0,0 +1,89 @@
+package structql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Define the values of the "auto" tag, which marks columns whose timestamps are
+// maintained by the database:
+//
+//  type Post struct {
+//    ID      int32     `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+//    Created time.Time `sql:"created_at" auto:"create"`
+//    Updated time.Time `sql:"updated_at" auto:"update"`
+//  }
+//
+// Both columns are set to the current time when a row is inserted, and the
+// "update" column is set to the current time whenever the row is updated.
+const (
+	autoCreate = "create"
+	autoUpdate = "update"
+)
+
+// autoMode returns the value of the "auto" tag of the given column.
+func autoMode(col column) string {
+	return col.field.Tag.Get("auto")
+}
+
+// autoColumn returns the column constraints that make the database maintain
+// the timestamp of the given column.  The constraints are appended to the
+// provided constraints of the column.
+func (conn *Connection) autoColumn(col column, opt string) (string, error) {
+	mode := autoMode(col)
+	if mode != autoCreate && mode != autoUpdate {
+		return "", fmt.Errorf("auto mode %q of column %q must be %q or %q", mode, col.name, autoCreate, autoUpdate)
+	}
+	if col.field.Type != timeType {
+		return "", fmt.Errorf("auto column %q must have type time.Time", col.name)
+	}
+
+	if !strings.Contains(strings.ToUpper(opt), "DEFAULT") {
+		opt = strings.TrimSpace(opt + " DEFAULT CURRENT_TIMESTAMP")
+	}
+	if mode == autoUpdate && conn.driver == MySQL {
+		opt += " ON UPDATE CURRENT_TIMESTAMP"
+	}
+	return opt, nil
+}
+
+// createAutoTrigger creates a PostgreSQL trigger that sets the given column of
+// the specified table to the current time whenever a row is updated, including
+// by statements that are not issued by this package.
+func (conn *Connection) createAutoTrigger(table string, col string) error {
+	// The trigger function is shared by every auto column; the name of the
+	// column is passed to it as an argument.
+	function := `CREATE OR REPLACE FUNCTION structql_auto_update() RETURNS trigger AS $$
+BEGIN
+	NEW := jsonb_populate_record(NEW, jsonb_build_object(TG_ARGV[0], now()));
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;`
+	if _, err := conn.exec(function); err != nil {
+		return fmt.Errorf("failed to create trigger function: %w", err)
+	}
+
+	trigger := fmt.Sprintf("%s_%s_auto", table, col)
+	stmts := []string{
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", trigger, table),
+		fmt.Sprintf("CREATE TRIGGER %s BEFORE UPDATE ON %s FOR EACH ROW EXECUTE PROCEDURE structql_auto_update(%s);", trigger, table, quoteLiteral(col)),
+	}
+	for _, stmt := range stmts {
+		if _, err := conn.exec(stmt); err != nil {
+			return fmt.Errorf("failed to create trigger %q: %w", trigger, err)
+		}
+	}
+	return nil
+}
+
+// autoUpdates returns the SET clause entries that set every "update" column of
+// the given columns to the current time.
+func autoUpdates(cols []column) []string {
+	sets := []string{}
+	for _, col := range cols {
+		if autoMode(col) == autoUpdate {
+			sets = append(sets, fmt.Sprintf("%s = CURRENT_TIMESTAMP", col.name))
+		}
+	}
+	return sets
+}
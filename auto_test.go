@@ -0,0 +1,95 @@
+// Package structql implements the Database structure.
+// This file contains tests for auto.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestAutoColumn tests the (*Connection).autoColumn() method.
+func TestAutoColumn(t *testing.T) {
+	type Post struct {
+		Created time.Time `sql:"created_at" auto:"create"`
+		Updated time.Time `sql:"updated_at" auto:"update"`
+		Stamped time.Time `sql:"stamped_at" auto:"update" opt:"DEFAULT now()"`
+		Touched time.Time `sql:"touched_at" auto:"touch"`
+		Counter int64     `sql:"counter" auto:"update"`
+	}
+
+	cols, _ := getColumns(reflect.TypeOf(Post{}))
+	tests := []struct {
+		driver  Driver
+		col     column
+		wantOpt string
+		wantErr bool
+	}{
+		{Postgres, cols[0], "DEFAULT CURRENT_TIMESTAMP", false},
+		{Postgres, cols[1], "DEFAULT CURRENT_TIMESTAMP", false},
+		{MySQL, cols[1], "DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP", false},
+		{Postgres, cols[2], "DEFAULT now()", false},
+		{Postgres, cols[3], "", true},
+		{Postgres, cols[4], "", true},
+	}
+	for i, test := range tests {
+		conn := &Connection{driver: test.driver}
+		haveOpt, err := conn.autoColumn(test.col, test.col.field.Tag.Get("opt"))
+		if haveOpt != test.wantOpt || (err != nil) != test.wantErr {
+			t.Errorf("TestAutoColumn()[%d] = (%q, %v), want (%q, error = %t).", i, haveOpt, err, test.wantOpt, test.wantErr)
+		}
+	}
+}
+
+// TestAutoUpdates tests the autoUpdates() function.
+func TestAutoUpdates(t *testing.T) {
+	type Post struct {
+		ID      int32     `sql:"id"`
+		Created time.Time `sql:"created_at" auto:"create"`
+		Updated time.Time `sql:"updated_at" auto:"update"`
+	}
+
+	cols, _ := getColumns(reflect.TypeOf(Post{}))
+	have := autoUpdates(cols)
+	want := []string{"updated_at = CURRENT_TIMESTAMP"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("TestAutoUpdates() = %v, want %v.", have, want)
+	}
+}
+
+// TestAutoTimestamps tests that auto columns are maintained by the database.
+func TestAutoTimestamps(t *testing.T) {
+	type Post struct {
+		ID      int32     `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Title   string    `sql:"title"`
+		Created time.Time `sql:"created_at" auto:"create"`
+		Updated time.Time `sql:"updated_at" auto:"update"`
+	}
+
+	conn := createTableUnsafe("Posts", Post{})
+	defer conn.Close()
+	defer conn.DropTable("Posts")
+
+	post := Post{Title: "Hello"}
+	if _, err := conn.InsertObject("Posts", &post); err != nil {
+		t.Fatalf("Failed to insert Post: %v.", err)
+	}
+	if post.Created.IsZero() || !post.Updated.Equal(post.Created) {
+		t.Fatalf("TestAutoTimestamps() = (%v, %v), want equal non-zero timestamps.", post.Created, post.Updated)
+	}
+
+	// Verify that an update advances only the "update" column.  The column is
+	// set to the start time of the updating transaction, which follows that of
+	// the inserting transaction, so no delay is needed.
+	if _, err := conn.UpdateWhere("Posts", map[string]interface{}{"title": "Goodbye"}, "id = $1", post.ID); err != nil {
+		t.Fatalf("Failed to update Post: %v.", err)
+	}
+	posts, err := conn.SelectFrom(Post{}, "Posts")
+	if err != nil || len(posts) != 1 {
+		t.Fatalf("TestAutoTimestamps() = %v (%v), want 1 Post.", posts, err)
+	}
+	have := posts[0].(Post)
+	if !have.Created.Equal(post.Created) || have.Updated.Before(post.Updated) || have.Updated.Equal(post.Created) {
+		t.Errorf("TestAutoTimestamps() = (%v, %v), want (%v, after %v).", have.Created, have.Updated, post.Created, post.Updated)
+	}
+}
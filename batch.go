@@ -0,0 +1,185 @@
+package structql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxParams is the maximum number of backreferences in a PostgreSQL statement.
+const maxParams = 65535
+
+// InsertObjects inserts every object in the given slice into the specified
+// table and returns the record IDs of the inserted rows in the order of the
+// slice.  As in InsertObject(), record IDs are only returned if the primary key
+// is a single integer column; otherwise, every record ID is 0.  The record IDs
+// are matched to the objects by position: this relies on PostgreSQL returning
+// the rows of a multi-row INSERT in the order of its VALUES list, which holds
+// in practice but is not documented as a guarantee.
+//
+// The objects are inserted with as few multi-row INSERT statements as the
+// PostgreSQL backreference limit allows.  All statements are executed in a
+// single transaction, so either every object is inserted or none of them are.
+// Unlike InsertObject(), conflicting rows are not ignored and cause the entire
+// batch to fail.  As in InsertObject(), the zero values of columns with the
+// "default" option are replaced with the DEFAULT value of the column.  The
+// insert hooks of each object are invoked within the transaction, so an
+// AfterInsert() error also rolls back the batch.
+func (conn *Connection) InsertObjects(table string, objects interface{}) ([]int, error) {
+	// Extract the underlying slice of objects.
+	slice := reflect.ValueOf(objects)
+	if slice.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("type %T is not a slice", objects)
+	}
+	objType := slice.Type().Elem()
+	if objType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %s is not a structure", objType)
+	}
+
+	// Locate the primary key of the objects.
+	keys, err := primaryKey(objType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Derive the columns that are written by the INSERT statements.
+	cols, names, err := conn.insertColumns(objType)
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("structure %s does not have any columns to insert", objType)
+	}
+
+	ids := make([]int, slice.Len())
+	if slice.Len() == 0 {
+		return ids, nil
+	}
+
+	// Return the record IDs if the key is a single integer column.
+	returning := ""
+	idCol, hasID := recordID(keys)
+	if hasID {
+		returning = " RETURNING " + idCol
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Insert the objects in chunks that fit within the backreference limit.
+	chunk := maxParams / len(cols)
+	for start := 0; start < slice.Len(); start += chunk {
+		end := start + chunk
+		if end > slice.Len() {
+			end = slice.Len()
+		}
+
+		// Construct a VALUES clause entry for each object in the chunk.
+		tuples := make([]string, 0, end-start)
+		vals := make([]interface{}, 0, (end-start)*len(cols))
+		for i := start; i < end; i++ {
+			objVal := slice.Index(i)
+			if err := runHook(objVal, beforeInsert); err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("failed to prepare object %d: %w", i, err)
+			}
+			if err := validateObject(objVal, cols); err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("invalid object %d: %w", i, err)
+			}
+			refs := make([]string, 0, len(cols))
+			for _, col := range cols {
+				fieldVal := objVal.FieldByIndex(col.index)
+				if usesDefault(col, fieldVal) {
+					refs = append(refs, "DEFAULT")
+					continue
+				}
+				val, err := insertValue(col, fieldVal)
+				if err != nil {
+					tx.Rollback()
+					return nil, fmt.Errorf("failed to encode object %d: %w", i, err)
+				}
+				vals = append(vals, val)
+				refs = append(refs, fmt.Sprintf("$%d", len(vals)))
+			}
+			tuples = append(tuples, "("+strings.Join(refs, ", ")+")")
+		}
+
+		stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s%s;", table, strings.Join(names, ", "), strings.Join(tuples, ", "), returning)
+		if err := conn.insertChunk(tx, stmt, vals, ids[start:end], hasID); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to insert objects %d to %d: %w", start, end-1, classifyError(err))
+		}
+	}
+
+	// Roll back the insertions if an object rejects its insertion after the fact.
+	for i := 0; i < slice.Len(); i++ {
+		if err := runHook(slice.Index(i), afterInsert); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to insert object %d: %w", i, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", classifyError(err))
+	}
+	return ids, nil
+}
+
+// insertChunk executes the given multi-row INSERT statement in the provided
+// transaction.  If the statement returns record IDs, they are stored in ids in
+// the order that they are returned (see InsertObjects()).
+func (conn *Connection) insertChunk(tx *sql.Tx, stmt string, vals []interface{}, ids []int, hasID bool) error {
+	if !hasID {
+		_, err := conn.txExec(tx, stmt, vals...)
+		return err
+	}
+
+	rows, err := conn.txQuery(tx, stmt, vals...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		if n >= len(ids) {
+			return fmt.Errorf("statement returned more than %d record IDs", len(ids))
+		}
+		if err := rows.Scan(&ids[n]); err != nil {
+			return fmt.Errorf("failed to scan record ID: %w", err)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if n != len(ids) {
+		return fmt.Errorf("statement returned %d record IDs, want %d", n, len(ids))
+	}
+	return nil
+}
+
+// insertColumns returns the columns of the given structure type that are
+// written when a row is inserted, along with their names.  Columns with a
+// SERIAL type and GENERATED columns are excluded since their values are
+// generated by the database.
+func (conn *Connection) insertColumns(objType reflect.Type) ([]column, []string, error) {
+	fields, untagged := getColumns(objType)
+	if err := conn.mapping.reportUntagged(objType, untagged); err != nil {
+		return nil, nil, err
+	}
+
+	cols := make([]column, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if !isGenerated(field) {
+			cols = append(cols, field)
+			names = append(names, field.name)
+		}
+	}
+	return cols, names, nil
+}
@@ -0,0 +1,79 @@
+// Package structql implements the Database structure.
+// This file contains tests for batch.go.
+package structql
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// TestInsertObjects tests the (*Connection).InsertObjects() method.
+func TestInsertObjects(t *testing.T) {
+	type Reading struct {
+		ID     int64   `sql:"id" typ:"BIGSERIAL" opt:"PRIMARY KEY"`
+		Sensor string  `sql:"sensor" opt:"UNIQUE"`
+		Value  float64 `sql:"value"`
+	}
+
+	conn := createTableUnsafe("Readings", Reading{})
+	defer conn.Close()
+	defer conn.DropTable("Readings")
+
+	// Insert enough readings to require more than one INSERT statement.
+	readings := make([]Reading, 40000)
+	for i := range readings {
+		readings[i] = Reading{int64(i + 1), fmt.Sprintf("sensor-%d", i), float64(i) / 2}
+	}
+
+	ids, err := conn.InsertObjects("Readings", readings)
+	if err != nil {
+		t.Fatalf("TestInsertObjects() - failed to insert readings: %v.", err)
+	}
+	if len(ids) != len(readings) {
+		t.Fatalf("TestInsertObjects() = %d, want %d record IDs.", len(ids), len(readings))
+	}
+	for i, id := range ids {
+		if id != i+1 {
+			t.Fatalf("TestInsertObjects()[%d] = %d, want record ID %d.", i, id, i+1)
+		}
+	}
+
+	have, err := conn.SelectFromWhere(Reading{}, "Readings", "id = %d", 12345)
+	if err != nil {
+		t.Fatalf("TestInsertObjects() - failed to select reading: %v.", err)
+	}
+	if len(have) != 1 || !reflect.DeepEqual(have[0], readings[12344]) {
+		t.Errorf("TestInsertObjects() = %v, want Reading %v.", have, readings[12344])
+	}
+
+	// Verify that each record ID identifies the row of its own object, which
+	// fails if the rows are not returned in the order of the VALUES list.
+	rows, err := conn.SelectFrom(Reading{}, "Readings")
+	if err != nil {
+		t.Fatalf("TestInsertObjects() - failed to select readings: %v.", err)
+	}
+	sensors := make(map[int]string, len(rows))
+	for _, row := range rows {
+		sensors[int(row.(Reading).ID)] = row.(Reading).Sensor
+	}
+	for i, id := range ids {
+		if sensors[id] != readings[i].Sensor {
+			t.Fatalf("TestInsertObjects()[%d] = %q, want record ID %d to identify %q.", i, sensors[id], id, readings[i].Sensor)
+		}
+	}
+
+	// Verify that a conflicting batch is rolled back in its entirety.
+	conflict := []Reading{{Sensor: "sensor-new"}, {Sensor: "sensor-0"}}
+	if _, err := conn.InsertObjects("Readings", conflict); err == nil {
+		t.Errorf("TestInsertObjects() - inserted a conflicting batch.")
+	}
+	if n, err := conn.CountRows("Readings"); err != nil || n != int64(len(readings)) {
+		t.Errorf("TestInsertObjects() = %d (%v), want %d rows after rollback.", n, err, len(readings))
+	}
+
+	// Verify that only slices of structures are accepted.
+	if _, err := conn.InsertObjects("Readings", readings[0]); err == nil {
+		t.Errorf("TestInsertObjects() - inserted a structure that is not in a slice.")
+	}
+}
@@ -11,8 +11,17 @@ import (
 
 // Connection wraps the sql.DB type.
 type Connection struct {
-	db   *sql.DB
-	name string
+	db     *sql.DB
+	name   string
+	driver Driver
+	// softDeletes records the soft-delete column of each known table.
+	softDeletes *softDeletes
+	// withDeleted reports whether queries include soft-deleted rows.
+	withDeleted bool
+	// mapping controls how mismatches between structures and tables are reported.
+	mapping Mapping
+	// stmts caches prepared statements; it is nil if the cache is disabled.
+	stmts *stmtCache
 }
 
 //ConnectionConfig are required to establish a connection to a Db
@@ -23,6 +32,13 @@ type ConnectionConfig struct {
 	User     string
 	Password string
 	Driver   Driver
+	// Mapping controls how mismatches between structures and tables are
+	// reported (see Mapping).  It defaults to MappingWarn.
+	Mapping Mapping
+	// StatementCacheSize is the maximum number of generated statements that are
+	// prepared once and reused.  The least recently used statement is closed
+	// when the cache is full.  A size of 0 disables the cache.
+	StatementCacheSize int
 }
 
 // Connect establishes and returns a connection to the SQL database
@@ -40,16 +56,29 @@ func Connect(creds ConnectionConfig) (*Connection, error) {
 	sqlDB, err := sql.Open("postgres", connectionInfo)
 	if err != nil {
 		logger.SQL("Failed to open SQL database %q.", database)
-		return nil, fmt.Errorf("failed to open SQL database using %q: %v", connectionInfo, err)
+		return nil, fmt.Errorf("failed to open SQL database using %q: %w", connectionInfo, err)
 	}
 
 	// Wrap the sql.DB object in the Database wrapper.
-	conn := Connection{sqlDB, database}
+	driver := creds.Driver
+	if driver == "" {
+		driver = Postgres
+	}
+	conn := Connection{
+		db:          sqlDB,
+		name:        database,
+		driver:      driver,
+		softDeletes: &softDeletes{columns: map[string]string{}},
+		mapping:     creds.Mapping,
+	}
+	if creds.StatementCacheSize > 0 {
+		conn.stmts = newStmtCache(creds.StatementCacheSize)
+	}
 
 	//Initiates connection to db.
 	if err := conn.db.Ping(); err != nil {
 		logger.SQL("Failed to connect to SQL database proxy.  Ensure the proxy is running and the appropriate environment variables are set.")
-		return nil, fmt.Errorf("failed to connect to SQL database proxy using %q: %v", connectionInfo, err)
+		return nil, fmt.Errorf("failed to connect to SQL database proxy using %q: %w", connectionInfo, err)
 	}
 
 	logger.SQL("Successfully connected to SQL database %q.", database)
@@ -58,27 +87,74 @@ func Connect(creds ConnectionConfig) (*Connection, error) {
 
 // Close closes the connection to the Database receiver.
 func (conn *Connection) Close() error {
+	if conn.stmts != nil {
+		conn.stmts.purge()
+	}
 	if err := conn.db.Close(); err != nil {
-		return fmt.Errorf("failed to close SQL database: %v", err)
+		return fmt.Errorf("failed to close SQL database: %w", err)
 	}
 	logger.SQL("Successfully closed connection to SQL database %q.", conn.name)
 	return nil
 }
 
+// Begin starts a transaction on the Connection receiver.
+func (conn *Connection) Begin() (*sql.Tx, error) {
+	tx, err := conn.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", classifyError(err))
+	}
+	return tx, nil
+}
+
 // exec executes the given SQL statement with the provided arguments on the Database receiver.
 func (conn *Connection) exec(stmt string, args ...interface{}) (sql.Result, error) {
-	result, err := conn.db.Exec(stmt, args...)
+	var result sql.Result
+	var err error
+	if conn.stmts != nil && isCacheable(stmt) {
+		err = conn.stmts.run(conn.db, stmt, func(prepared *sql.Stmt) error {
+			result, err = prepared.Exec(args...)
+			return err
+		})
+	} else {
+		result, err = conn.db.Exec(stmt, args...)
+	}
+
+	// Prepared statements may not survive a change to the schema.
+	if conn.stmts != nil && isSchemaChange(stmt) {
+		conn.stmts.purge()
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed To execute SQL statement %q (result %v): %v", stmt, result, err)
+		return nil, fmt.Errorf("failed To execute SQL statement %q (result %v): %w", stmt, result, classifyError(err))
 	}
 	return result, nil
 }
 
-// query queries the Database receiver with the given SQL query.
-func (conn *Connection) query(stmt string) (*sql.Rows, error) {
+// query queries the Database receiver with the given SQL query and arguments.
+func (conn *Connection) query(stmt string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	var err error
+	if conn.stmts != nil && isCacheable(stmt) {
+		err = conn.stmts.run(conn.db, stmt, func(prepared *sql.Stmt) error {
+			rows, err = prepared.Query(args...)
+			return err
+		})
+	} else {
+		rows, err = conn.db.Query(stmt, args...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed To execute SQL query: %w", classifyError(err))
+	}
+	return rows, nil
+}
+
+// queryLiteral queries the Database receiver with the given SQL query, whose
+// values are formatted into its text (e.g., by SelectFromWhere()).  The
+// statement cache is bypassed since the text of such queries rarely repeats, so
+// caching them would only evict reusable statements.
+func (conn *Connection) queryLiteral(stmt string) (*sql.Rows, error) {
 	rows, err := conn.db.Query(stmt)
 	if err != nil {
-		return nil, fmt.Errorf("failed To execute SQL query: %v", err)
+		return nil, fmt.Errorf("failed To execute SQL query: %w", classifyError(err))
 	}
 	return rows, nil
 }
@@ -86,5 +162,19 @@ func (conn *Connection) query(stmt string) (*sql.Rows, error) {
 // queryRow executes the given SQL statement with the provided arguments on the
 // Database receiver and returns the row resulting from the query.
 func (conn *Connection) queryRow(stmt string, args ...interface{}) *sql.Row {
-	return conn.db.QueryRow(stmt, args...)
+	if conn.stmts == nil || !isCacheable(stmt) {
+		return conn.db.QueryRow(stmt, args...)
+	}
+
+	var row *sql.Row
+	err := conn.stmts.run(conn.db, stmt, func(prepared *sql.Stmt) error {
+		row = prepared.QueryRow(args...)
+		return row.Err()
+	})
+	if row == nil {
+		// The statement could not be prepared; report the error when the row is scanned.
+		logger.Warning("Failed to prepare SQL statement %q: %v.", stmt, err)
+		return conn.db.QueryRow(stmt, args...)
+	}
+	return row
 }
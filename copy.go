@@ -0,0 +1,146 @@
+package structql
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/lib/pq"
+)
+
+// CopyFrom loads every object in the given slice into the specified table with
+// the PostgreSQL COPY command, which is considerably faster than INSERT for
+// large numbers of rows.  As with InsertObject(), columns with a SERIAL type
+// and GENERATED columns are left for the database to populate.  Since COPY
+// cannot default individual values, the "default" option is ignored and zero
+// values are copied as they are.  The objects are copied in a single
+// transaction, and the number of copied rows is returned.  Only the
+// BeforeInsert() hook of each object is invoked.
+func (conn *Connection) CopyFrom(table string, objects interface{}) (int64, error) {
+	// Extract the underlying slice of objects.
+	slice := reflect.ValueOf(objects)
+	if slice.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("type %T is not a slice", objects)
+	}
+
+	i := 0
+	next := func() (reflect.Value, bool) {
+		if i >= slice.Len() {
+			return reflect.Value{}, false
+		}
+		i++
+		return slice.Index(i - 1), true
+	}
+	return conn.copyFrom(table, slice.Type().Elem(), next)
+}
+
+// CopyFromChannel behaves like CopyFrom() but receives the objects to copy
+// from the given channel until it is closed.  This allows an arbitrary number
+// of objects to be loaded without holding them in memory at the same time:
+//
+//  people := make(chan Person)
+//  go func() {
+//    defer close(people)
+//    for ... {
+//      people <- person
+//    }
+//  }()
+//  n, err := conn.CopyFromChannel("people", people)
+//
+// The sender must close the channel even if the copy fails: the remaining
+// objects are then received and discarded in the background, so that a sender
+// which is blocked on the channel does not leak.
+func (conn *Connection) CopyFromChannel(table string, objects interface{}) (int64, error) {
+	// Extract the underlying channel of objects.
+	channel := reflect.ValueOf(objects)
+	if channel.Kind() != reflect.Chan || channel.Type().ChanDir()&reflect.RecvDir == 0 {
+		return 0, fmt.Errorf("type %T is not a receivable channel", objects)
+	}
+
+	next := func() (reflect.Value, bool) {
+		return channel.Recv()
+	}
+	n, err := conn.copyFrom(table, channel.Type().Elem(), next)
+	if err != nil {
+		// Drain the channel until the sender closes it.
+		go func() {
+			for _, ok := channel.Recv(); ok; _, ok = channel.Recv() {
+			}
+		}()
+	}
+	return n, err
+}
+
+// copyFrom copies the objects of the given type that are yielded by next into
+// the specified table and returns the number of copied rows.
+func (conn *Connection) copyFrom(table string, objType reflect.Type, next func() (reflect.Value, bool)) (int64, error) {
+	if objType.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("type %s is not a structure", objType)
+	}
+
+	// Derive the columns that are written by the COPY command.
+	cols, names, err := conn.insertColumns(objType)
+	if err != nil {
+		return 0, err
+	}
+	if len(cols) == 0 {
+		return 0, fmt.Errorf("structure %s does not have any columns to copy", objType)
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn(table, names...))
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to prepare COPY statement: %w", err)
+	}
+
+	// Buffer each object in the COPY statement.
+	var n int64
+	for objVal, ok := next(); ok; objVal, ok = next() {
+		objVal = addressable(objVal)
+		if err := runHook(objVal, beforeInsert); err != nil {
+			stmt.Close()
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to prepare object %d: %w", n, err)
+		}
+		if err := validateObject(objVal, cols); err != nil {
+			stmt.Close()
+			tx.Rollback()
+			return 0, fmt.Errorf("invalid object %d: %w", n, err)
+		}
+		vals := make([]interface{}, 0, len(cols))
+		for _, col := range cols {
+			val, err := insertValue(col, objVal.FieldByIndex(col.index))
+			if err != nil {
+				stmt.Close()
+				tx.Rollback()
+				return 0, fmt.Errorf("failed to encode object %d: %w", n, err)
+			}
+			vals = append(vals, val)
+		}
+		if _, err := stmt.Exec(vals...); err != nil {
+			stmt.Close()
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to copy object %d: %w", n, classifyError(err))
+		}
+		n++
+	}
+
+	// Flush the buffered objects to the database.
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to flush COPY statement: %w", classifyError(err))
+	}
+	if err := stmt.Close(); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to close COPY statement: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", classifyError(err))
+	}
+	return n, nil
+}
@@ -0,0 +1,82 @@
+// Package structql implements the Database structure.
+// This file contains tests for copy.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestCopyFromChannelDrain tests that CopyFromChannel() drains the channel of a
+// failed copy so that its sender is not blocked forever.
+func TestCopyFromChannelDrain(t *testing.T) {
+	channel := make(chan int)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(channel)
+		for i := 0; i < 3; i++ {
+			channel <- i
+		}
+	}()
+
+	conn := &Connection{driver: Postgres}
+	if _, err := conn.CopyFromChannel("People", channel); err == nil {
+		t.Errorf("TestCopyFromChannelDrain() - copied integers as though they were structures.")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("TestCopyFromChannelDrain() - sender is still blocked after the copy failed.")
+	}
+}
+
+// TestCopyFrom tests the (*Connection).CopyFrom() and
+// (*Connection).CopyFromChannel() methods.
+func TestCopyFrom(t *testing.T) {
+	type Person struct {
+		ID   int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Name string `sql:"name"`
+		Age  int32  `sql:"age"`
+	}
+
+	conn := createTableUnsafe("People", Person{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	people := []Person{{1, "Ann", 31}, {2, "Bob", 42}}
+	if n, err := conn.CopyFrom("People", people); err != nil || n != 2 {
+		t.Fatalf("TestCopyFrom() = %d (%v), want 2 rows copied.", n, err)
+	}
+
+	channel := make(chan Person)
+	go func() {
+		defer close(channel)
+		channel <- Person{Name: "Cat", Age: 53}
+	}()
+	if n, err := conn.CopyFromChannel("People", channel); err != nil || n != 1 {
+		t.Fatalf("TestCopyFrom() = %d (%v), want 1 row copied.", n, err)
+	}
+
+	rows, err := conn.SelectFrom(Person{}, "People")
+	if err != nil {
+		t.Fatalf("TestCopyFrom() - failed to select People: %v.", err)
+	}
+	havePeople := make([]Person, len(rows))
+	for i, row := range rows {
+		havePeople[i] = row.(Person)
+	}
+	wantPeople := append(people, Person{3, "Cat", 53})
+	if !reflect.DeepEqual(havePeople, wantPeople) {
+		t.Errorf("TestCopyFrom() = %v, want People %v.", havePeople, wantPeople)
+	}
+
+	// Verify that only slices and channels are accepted.
+	if _, err := conn.CopyFrom("People", people[0]); err == nil {
+		t.Errorf("TestCopyFrom() - copied a structure that is not in a slice.")
+	}
+	if _, err := conn.CopyFromChannel("People", people); err == nil {
+		t.Errorf("TestCopyFrom() - copied a slice as though it were a channel.")
+	}
+}
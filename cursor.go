@@ -0,0 +1,148 @@
+package structql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// cursorCount is the number of cursors that have been declared; it gives each
+// cursor a unique name.
+var cursorCount uint64
+
+// Cursor is a PostgreSQL server-side cursor that fetches the rows of a query in
+// chunks of a chosen size.  Only the fetched chunk is held in memory, so huge
+// tables can be processed with bounded memory:
+//
+//  tx, err := conn.Begin()
+//  ...
+//  defer tx.Rollback()
+//  cur, err := conn.Cursor(tx, Person{}, "SELECT id, name FROM people ORDER BY id")
+//  ...
+//  var people []Person
+//  for {
+//    n, err := cur.Fetch(&people, 1000)
+//    if err != nil {
+//      return err
+//    }
+//    if n == 0 {
+//      break
+//    }
+//    ...
+//  }
+//
+// A cursor lives only as long as its transaction.  To resume an interrupted job,
+// record the Position() of the cursor, declare a new cursor over the same
+// (deterministically ordered) query, and Skip() to the recorded position.
+type Cursor struct {
+	conn *Connection
+	tx   *sql.Tx
+	// name is the name of the cursor in the database.
+	name string
+	// template is the structure type of the fetched rows.
+	template reflect.Type
+	// position is the number of rows that have been fetched or skipped.
+	position int64
+}
+
+// Cursor declares a server-side cursor for the given query in the provided
+// transaction and returns it.  The rows of the query are converted into
+// structures with the type of the given object, and the arguments of the query
+// are referenced as $1, $2, etc.  Cursors are only supported by PostgreSQL.
+func (conn *Connection) Cursor(tx *sql.Tx, object interface{}, query string, args ...interface{}) (*Cursor, error) {
+	if conn.driver != Postgres {
+		return nil, fmt.Errorf("cursors are not supported by the %s driver", conn.driver)
+	}
+	template := reflect.TypeOf(object)
+	if template == nil || template.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %T is not a structure", object)
+	}
+
+	name := fmt.Sprintf("structql_cursor_%d", atomic.AddUint64(&cursorCount, 1))
+	stmt := fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", name, query)
+	if _, err := conn.txExec(tx, stmt, args...); err != nil {
+		return nil, fmt.Errorf("failed to declare cursor with %q: %w", stmt, classifyError(err))
+	}
+	return &Cursor{conn: conn, tx: tx, name: name, template: template}, nil
+}
+
+// Fetch fetches up to n of the next rows of the Cursor receiver into the given
+// destination, which must be a pointer to a slice of structures with the type
+// of the cursor object.  The slice is truncated before the rows are appended,
+// so its backing array is reused between fetches.  The number of fetched rows
+// is returned; it is 0 once the rows are exhausted.  If an error is returned,
+// the position of the cursor is left unchanged so that a job resumed from the
+// Position() fetches the rows of the failed chunk again.
+func (cur *Cursor) Fetch(dest interface{}, n int) (int, error) {
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.IsNil() || destVal.Elem().Kind() != reflect.Slice || destVal.Elem().Type().Elem() != cur.template {
+		return 0, fmt.Errorf("destination of type %T is not a non-nil pointer to a slice of type %s", dest, cur.template)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("fetch size %d is not positive", n)
+	}
+
+	stmt := fmt.Sprintf("FETCH FORWARD %d FROM %s", n, cur.name)
+	rows, err := cur.conn.txQuery(cur.tx, stmt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch from cursor %s: %w", cur.name, classifyError(err))
+	}
+	defer rows.Close()
+
+	parser, err := newRowParser(rows, cur.template, cur.conn.mapping)
+	if err != nil {
+		return 0, err
+	}
+
+	slice := destVal.Elem().Slice(0, 0)
+	for rows.Next() {
+		vessel, err := parser.parse(rows)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse row %d: %w", cur.position+int64(slice.Len()), err)
+		}
+		slice = reflect.Append(slice, vessel)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read row %d: %w", cur.position+int64(slice.Len()), classifyError(err))
+	}
+
+	// The position only advances once the whole chunk has been read.
+	cur.position += int64(slice.Len())
+	destVal.Elem().Set(slice)
+	return slice.Len(), nil
+}
+
+// Skip moves the Cursor receiver forward by n rows without fetching them and
+// returns the number of rows that were skipped.
+func (cur *Cursor) Skip(n int64) (int64, error) {
+	if n < 0 {
+		return 0, fmt.Errorf("skip size %d is negative", n)
+	}
+	stmt := fmt.Sprintf("MOVE FORWARD %d IN %s", n, cur.name)
+	result, err := cur.conn.txExec(cur.tx, stmt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move cursor %s: %w", cur.name, classifyError(err))
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count the rows skipped by cursor %s: %w", cur.name, err)
+	}
+	cur.position += moved
+	return moved, nil
+}
+
+// Position returns the number of rows that the Cursor receiver has fetched or
+// skipped.
+func (cur *Cursor) Position() int64 {
+	return cur.position
+}
+
+// Close closes the Cursor receiver.  Cursors are also closed automatically when
+// their transaction ends.
+func (cur *Cursor) Close() error {
+	if _, err := cur.conn.txExec(cur.tx, fmt.Sprintf("CLOSE %s", cur.name)); err != nil {
+		return fmt.Errorf("failed to close cursor %s: %w", cur.name, classifyError(err))
+	}
+	return nil
+}
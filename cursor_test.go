@@ -0,0 +1,118 @@
+// Package structql implements the Database structure.
+// This file contains tests for cursor.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCursorErrors tests the argument checks of the Cursor type.
+func TestCursorErrors(t *testing.T) {
+	type Person struct {
+		ID int32 `sql:"id"`
+	}
+	type Other struct {
+		ID int32 `sql:"id"`
+	}
+
+	mysql := &Connection{driver: MySQL}
+	if _, err := mysql.Cursor(nil, Person{}, "SELECT id FROM people"); err == nil {
+		t.Errorf("TestCursorErrors() - declared a cursor with the MySQL driver.")
+	}
+	postgres := &Connection{driver: Postgres}
+	if _, err := postgres.Cursor(nil, 7, "SELECT id FROM people"); err == nil {
+		t.Errorf("TestCursorErrors() - declared a cursor over a non-structure type.")
+	}
+
+	cur := &Cursor{conn: postgres, template: reflect.TypeOf(Person{})}
+	tests := []struct {
+		dest interface{}
+		n    int
+	}{
+		{[]Person{}, 10},
+		{(*[]Person)(nil), 10},
+		{&Person{}, 10},
+		{&[]Other{}, 10},
+		{&[]*Person{}, 10},
+		{&[]Person{}, 0},
+		{&[]Person{}, -1},
+	}
+	for i, test := range tests {
+		if _, err := cur.Fetch(test.dest, test.n); err == nil {
+			t.Errorf("TestCursorErrors()[%d] = nil, want error for destination %T and size %d.", i, test.dest, test.n)
+		}
+	}
+	if _, err := cur.Skip(-1); err == nil {
+		t.Errorf("TestCursorErrors() - skipped a negative number of rows.")
+	}
+}
+
+// TestCursor tests the Cursor() method of the Connection type.
+func TestCursor(t *testing.T) {
+	type Person struct {
+		ID   int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Name string `sql:"name"`
+	}
+
+	conn := createTableUnsafe("People", Person{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	people := []Person{{1, "Ann"}, {2, "Bob"}, {3, "Cat"}, {4, "Dan"}, {5, "Eve"}}
+	if _, err := conn.InsertObjects("People", people); err != nil {
+		t.Fatalf("Failed to insert People: %v.", err)
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatalf("TestCursor() - failed to begin transaction: %v.", err)
+	}
+	defer tx.Rollback()
+
+	cur, err := conn.Cursor(tx, Person{}, "SELECT id, name FROM People WHERE id > $1 ORDER BY id", 0)
+	if err != nil {
+		t.Fatalf("TestCursor() - failed to declare cursor: %v.", err)
+	}
+
+	// Skip the first row, then fetch the rest in chunks of two.
+	if n, err := cur.Skip(1); err != nil || n != 1 {
+		t.Errorf("TestCursor() = %d (%v), want 1 skipped row.", n, err)
+	}
+	tests := []struct {
+		want         []Person
+		wantPosition int64
+	}{
+		{[]Person{{2, "Bob"}, {3, "Cat"}}, 3},
+		{[]Person{{4, "Dan"}, {5, "Eve"}}, 5},
+		{[]Person{}, 5},
+	}
+	var have []Person
+	for i, test := range tests {
+		n, err := cur.Fetch(&have, 2)
+		if err != nil {
+			t.Fatalf("TestCursor()[%d] - failed to fetch: %v.", i, err)
+		}
+		if n != len(test.want) || !reflect.DeepEqual(have, test.want) {
+			t.Errorf("TestCursor()[%d] = %v, want people %v.", i, have, test.want)
+		}
+		if position := cur.Position(); position != test.wantPosition {
+			t.Errorf("TestCursor()[%d] = %d, want position %d.", i, position, test.wantPosition)
+		}
+	}
+	if err := cur.Close(); err != nil {
+		t.Errorf("TestCursor() = %v, want the cursor to close.", err)
+	}
+
+	// Verify that a chunk which fails to parse does not advance the position.
+	query := "SELECT CASE WHEN id < 3 THEN id::TEXT ELSE 'many' END AS id, name FROM People ORDER BY People.id"
+	if cur, err = conn.Cursor(tx, Person{}, query); err != nil {
+		t.Fatalf("TestCursor() - failed to declare cursor: %v.", err)
+	}
+	if n, err := cur.Fetch(&have, 5); err == nil {
+		t.Errorf("TestCursor() = %d, want error for unparsable row.", n)
+	}
+	if position := cur.Position(); position != 0 {
+		t.Errorf("TestCursor() = %d, want position 0 after failed fetch.", position)
+	}
+}
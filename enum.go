@@ -0,0 +1,204 @@
+package structql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// enums holds the values of every enumeration registered with RegisterEnum().
+var enums = struct {
+	sync.RWMutex
+	values map[string][]string
+}{values: map[string][]string{}}
+
+// RegisterEnum registers an enumeration with the given name and values.  Fields
+// are associated with an enumeration through the "enum" tag:
+//
+//  type JobStatus string
+//
+//  const (
+//    Pending JobStatus = "pending"
+//    Running JobStatus = "running"
+//  )
+//
+//  type Job struct {
+//    ID     int32     `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+//    Status JobStatus `sql:"status" enum:"job_status"`
+//  }
+//
+//  err := RegisterEnum("job_status", []string{"pending", "running"})
+//
+// Enumerated fields may have a string or integer kind; the value of an integer
+// field is the index of its label in the registered values.  Registering an
+// existing enumeration replaces its values; use (*Connection).MigrateEnum() to
+// add the new values to the database.
+func RegisterEnum(name string, values []string) error {
+	if name == "" {
+		return fmt.Errorf("enum name must not be empty")
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("enum %q must have at least one value", name)
+	}
+
+	seen := map[string]bool{}
+	for _, value := range values {
+		if seen[value] {
+			return fmt.Errorf("enum %q has duplicate value %q", name, value)
+		}
+		seen[value] = true
+	}
+
+	enums.Lock()
+	defer enums.Unlock()
+	enums.values[name] = append([]string{}, values...)
+	return nil
+}
+
+// enumValues returns the registered values of the enumeration with the given name.
+func enumValues(name string) ([]string, error) {
+	enums.RLock()
+	defer enums.RUnlock()
+	values, ok := enums.values[name]
+	if !ok {
+		return nil, fmt.Errorf("enum %q is not registered", name)
+	}
+	return values, nil
+}
+
+// encodeEnum validates the value of the given enumerated field and returns the
+// label that is stored in the database.
+func encodeEnum(name string, val reflect.Value) (string, error) {
+	values, err := enumValues(name)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case val.Kind() == reflect.String:
+		for _, value := range values {
+			if value == val.String() {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("value %q is not a member of enum %q", val.String(), name)
+	case isInteger(val.Type()):
+		i := toInt64(val)
+		if i < 0 || i >= int64(len(values)) {
+			return "", fmt.Errorf("value %d is out of range for enum %q", i, name)
+		}
+		return values[i], nil
+	}
+	return "", fmt.Errorf("type %s cannot hold a value of enum %q", val.Type(), name)
+}
+
+// toInt64 returns the value of the given integer as an int64.
+func toInt64(val reflect.Value) int64 {
+	switch val.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(val.Uint())
+	}
+	return val.Int()
+}
+
+// enumIndex scans the label of an enumeration into its index so that it can be
+// assigned to a field of an integer kind.
+type enumIndex struct {
+	name  string
+	index int64
+}
+
+// Scan implements the sql.Scanner interface.
+func (e *enumIndex) Scan(src interface{}) error {
+	var label string
+	switch src := src.(type) {
+	case []byte:
+		label = string(src)
+	case string:
+		label = src
+	default:
+		return fmt.Errorf("cannot scan type %T into enum %q", src, e.name)
+	}
+
+	values, err := enumValues(e.name)
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		if value == label {
+			e.index = int64(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("value %q is not a member of enum %q", label, e.name)
+}
+
+// enumColumn returns the column type and constraint of the column with the
+// given name that holds values of the given enumeration.  On PostgreSQL, the
+// enumeration is created as a type if it does not already exist; on other
+// dialects, the values are enforced with a CHECK constraint.
+func (conn *Connection) enumColumn(name string, col string) (string, string, error) {
+	values, err := enumValues(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	if conn.driver == Postgres {
+		if err := conn.createEnum(name, values); err != nil {
+			return "", "", err
+		}
+		return name, "", nil
+	}
+	check := fmt.Sprintf("CHECK (%s IN (%s))", col, quoteLiterals(values))
+	return "VARCHAR(255)", check, nil
+}
+
+// createEnum creates the PostgreSQL enumerated type with the given name and
+// values if it does not already exist.
+func (conn *Connection) createEnum(name string, values []string) error {
+	stmt := fmt.Sprintf("DO $$ BEGIN CREATE TYPE %s AS ENUM (%s); EXCEPTION WHEN duplicate_object THEN NULL; END $$;", name, quoteLiterals(values))
+	_, err := conn.exec(stmt)
+	return err
+}
+
+// MigrateEnum brings the PostgreSQL type of the registered enumeration with the
+// given name up to date by creating it if necessary and adding every
+// registered value that the type does not yet have.  New values are appended
+// to the end of the type.  Note that PostgreSQL versions prior to 12 do not
+// allow this operation inside a transaction.
+func (conn *Connection) MigrateEnum(name string) error {
+	if conn.driver != Postgres {
+		return fmt.Errorf("enum migrations are not supported by the %s driver; recreate the CHECK constraint instead", conn.driver)
+	}
+
+	values, err := enumValues(name)
+	if err != nil {
+		return err
+	}
+	if err := conn.createEnum(name, values); err != nil {
+		return fmt.Errorf("failed to create enum %q: %w", name, err)
+	}
+	for _, value := range values {
+		stmt := fmt.Sprintf("ALTER TYPE %s ADD VALUE IF NOT EXISTS %s;", name, quoteLiteral(value))
+		if _, err := conn.exec(stmt); err != nil {
+			return fmt.Errorf("failed to add value %q to enum %q: %w", value, name, err)
+		}
+	}
+	return nil
+}
+
+// quoteLiteral quotes the given string as an SQL string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// quoteLiterals quotes each of the given strings as an SQL string literal and
+// joins the results into a comma-separated list.
+func quoteLiterals(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quoteLiteral(value)
+	}
+	return strings.Join(quoted, ", ")
+}
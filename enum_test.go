@@ -0,0 +1,127 @@
+// Package structql implements the Database structure.
+// This file contains tests for enum.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRegisterEnum tests the RegisterEnum() function.
+func TestRegisterEnum(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		wantErr bool
+	}{
+		{"", []string{"a"}, true},
+		{"empty", []string{}, true},
+		{"duplicate", []string{"a", "b", "a"}, true},
+		{"colour", []string{"red", "green", "blue"}, false},
+	}
+	for i, test := range tests {
+		err := RegisterEnum(test.name, test.values)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestRegisterEnum()[%d] = %v, want error %t.", i, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if have, _ := enumValues(test.name); !reflect.DeepEqual(have, test.values) {
+			t.Errorf("TestRegisterEnum()[%d] = %v, want values %v.", i, have, test.values)
+		}
+	}
+}
+
+// TestEncodeEnum tests the encodeEnum() function.
+func TestEncodeEnum(t *testing.T) {
+	type Status string
+	type Level int
+
+	if err := RegisterEnum("test_status", []string{"pending", "done"}); err != nil {
+		t.Fatalf("Failed to register enum: %v.", err)
+	}
+
+	tests := []struct {
+		name      string
+		value     interface{}
+		wantLabel string
+		wantErr   bool
+	}{
+		{"test_status", Status("done"), "done", false},
+		{"test_status", Status("lost"), "", true},
+		{"test_status", Level(0), "pending", false},
+		{"test_status", Level(2), "", true},
+		{"test_status", 1.5, "", true},
+		{"test_missing", Status("done"), "", true},
+	}
+	for i, test := range tests {
+		haveLabel, err := encodeEnum(test.name, reflect.ValueOf(test.value))
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestEncodeEnum()[%d] = %v, want error %t.", i, err, test.wantErr)
+		}
+		if haveLabel != test.wantLabel {
+			t.Errorf("TestEncodeEnum()[%d] = %q, want label %q.", i, haveLabel, test.wantLabel)
+		}
+	}
+}
+
+// TestEnumObject tests the storage of enumerated fields through the
+// (*Connection).CreateTableFromObject(), (*Connection).InsertObject(), and
+// (*Connection).SelectFrom() methods.
+func TestEnumObject(t *testing.T) {
+	type Status string
+	type Priority int
+
+	type Job struct {
+		ID       int32    `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Status   Status   `sql:"status" enum:"job_status"`
+		Priority Priority `sql:"priority" enum:"job_priority"`
+	}
+
+	if err := RegisterEnum("job_status", []string{"pending", "running"}); err != nil {
+		t.Fatalf("Failed to register enum: %v.", err)
+	}
+	if err := RegisterEnum("job_priority", []string{"low", "high"}); err != nil {
+		t.Fatalf("Failed to register enum: %v.", err)
+	}
+
+	conn := createTableUnsafe("Jobs", Job{})
+	defer conn.Close()
+	defer conn.exec("DROP TYPE IF EXISTS job_status, job_priority;")
+	defer conn.DropTable("Jobs")
+
+	if _, err := conn.InsertObject("Jobs", Job{Status: "failed"}); err == nil {
+		t.Errorf("TestEnumObject() - inserted a Job with an invalid status.")
+	}
+
+	want := Job{1, "running", 1}
+	if _, err := conn.InsertObject("Jobs", want); err != nil {
+		t.Fatalf("TestEnumObject() - failed to insert Job %v: %v.", want, err)
+	}
+
+	// Add a value to the enumeration and verify that it can be stored.
+	if err := RegisterEnum("job_status", []string{"pending", "running", "failed"}); err != nil {
+		t.Fatalf("Failed to register enum: %v.", err)
+	}
+	if err := conn.MigrateEnum("job_status"); err != nil {
+		t.Fatalf("TestEnumObject() - failed to migrate enum: %v.", err)
+	}
+	failed := Job{2, "failed", 0}
+	if _, err := conn.InsertObject("Jobs", failed); err != nil {
+		t.Fatalf("TestEnumObject() - failed to insert Job %v: %v.", failed, err)
+	}
+
+	jobs, err := conn.SelectFrom(Job{}, "Jobs")
+	if err != nil {
+		t.Fatalf("TestEnumObject() - failed to select Jobs: %v.", err)
+	}
+	haveJobs := make([]Job, len(jobs))
+	for i, job := range jobs {
+		haveJobs[i] = job.(Job)
+	}
+	if wantJobs := []Job{want, failed}; !reflect.DeepEqual(haveJobs, wantJobs) {
+		t.Errorf("TestEnumObject() = %v, want Jobs %v.", haveJobs, wantJobs)
+	}
+}
@@ -0,0 +1,148 @@
+package structql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// Define the errors that classify the failures reported by the database.  The
+// errors returned by a Connection wrap these errors, so they can be detected
+// with errors.Is():
+//
+//  err := conn.UpdateObject("people", person)
+//  if errors.Is(err, structql.ErrNotFound) {
+//    ...
+//  }
+//
+// The original driver error (e.g., a *pq.Error) remains available through
+// errors.As().
+var (
+	// ErrNotFound indicates that no row matches the object or query.
+	ErrNotFound = errors.New("no matching row")
+	// ErrForeignKeyViolation indicates that a row references a missing row.
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	// ErrCheckViolation indicates that a row violates a CHECK constraint.
+	ErrCheckViolation = errors.New("check constraint violation")
+	// ErrSerialization indicates that a transaction conflicted with another
+	// transaction (including deadlocks) and may succeed if it is retried.
+	ErrSerialization = errors.New("serialization failure")
+	// ErrTimeout indicates that a statement was cancelled because it exceeded a
+	// time limit or could not acquire a lock in time.
+	ErrTimeout = errors.New("timeout")
+)
+
+// ErrUniqueViolation is returned when a row conflicts with an existing row on a
+// unique constraint (including the primary key).  Use errors.As() to detect it:
+//
+//  var unique *structql.ErrUniqueViolation
+//  if errors.As(err, &unique) {
+//    log.Printf("duplicate value for constraint %q", unique.Constraint)
+//  }
+type ErrUniqueViolation struct {
+	// Constraint is the name of the violated constraint, if it is known.
+	Constraint string
+	// Err is the error reported by the database driver.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ErrUniqueViolation) Error() string {
+	return fmt.Sprintf("unique constraint %q violated: %v", e.Constraint, e.Err)
+}
+
+// Unwrap returns the error reported by the database driver.
+func (e *ErrUniqueViolation) Unwrap() error {
+	return e.Err
+}
+
+// driverError associates an error reported by the database driver with one of
+// the classifying errors above.
+type driverError struct {
+	// kind is the classifying error (e.g., ErrTimeout).
+	kind error
+	// err is the error reported by the database driver.
+	err error
+}
+
+// Error implements the error interface.
+func (e *driverError) Error() string {
+	return fmt.Sprintf("%v: %v", e.kind, e.err)
+}
+
+// Is reports whether the driverError receiver has the given classification.
+func (e *driverError) Is(target error) bool {
+	return e.kind == target
+}
+
+// Unwrap returns the error reported by the database driver.
+func (e *driverError) Unwrap() error {
+	return e.err
+}
+
+// Define the PostgreSQL error codes that are classified by classifyError().  For
+// more information, see https://www.postgresql.org/docs/current/errcodes-appendix.html.
+var pqErrorKinds = map[pq.ErrorCode]error{
+	"23503": ErrForeignKeyViolation, // foreign_key_violation
+	"23514": ErrCheckViolation,      // check_violation
+	"40001": ErrSerialization,       // serialization_failure
+	"40P01": ErrSerialization,       // deadlock_detected
+	"55P03": ErrTimeout,             // lock_not_available
+	"57014": ErrTimeout,             // query_canceled
+}
+
+// Define the MySQL error numbers that are classified by classifyError().  For
+// more information, see https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html.
+var mysqlErrorKinds = map[uint16]error{
+	1205: ErrTimeout,             // ER_LOCK_WAIT_TIMEOUT
+	1213: ErrSerialization,       // ER_LOCK_DEADLOCK
+	1451: ErrForeignKeyViolation, // ER_ROW_IS_REFERENCED_2
+	1452: ErrForeignKeyViolation, // ER_NO_REFERENCED_ROW_2
+	3024: ErrTimeout,             // ER_QUERY_TIMEOUT
+	3819: ErrCheckViolation,      // ER_CHECK_CONSTRAINT_VIOLATED
+}
+
+// mysqlDuplicateKey extracts the name of the key from the message of a MySQL
+// duplicate entry error.
+var mysqlDuplicateKey = regexp.MustCompile(`for key '([^']*)'`)
+
+// classifyError wraps the given error reported by the database driver with the
+// error that classifies it, if any.  Unclassified errors are returned as is.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pqErr *pq.Error
+	var mysqlErr *mysql.MySQLError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return &driverError{ErrNotFound, err}
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return &driverError{ErrTimeout, err}
+	case errors.As(err, &pqErr):
+		if pqErr.Code == "23505" { // unique_violation
+			return &ErrUniqueViolation{pqErr.Constraint, err}
+		}
+		if kind, ok := pqErrorKinds[pqErr.Code]; ok {
+			return &driverError{kind, err}
+		}
+	case errors.As(err, &mysqlErr):
+		if mysqlErr.Number == 1062 { // ER_DUP_ENTRY
+			constraint := ""
+			if match := mysqlDuplicateKey.FindStringSubmatch(mysqlErr.Message); match != nil {
+				constraint = match[1]
+			}
+			return &ErrUniqueViolation{constraint, err}
+		}
+		if kind, ok := mysqlErrorKinds[mysqlErr.Number]; ok {
+			return &driverError{kind, err}
+		}
+	}
+	return err
+}
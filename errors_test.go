@@ -0,0 +1,86 @@
+// Package structql implements the Database structure.
+// This file contains tests for errors.go.
+package structql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// TestClassifyError tests the classifyError() function.
+func TestClassifyError(t *testing.T) {
+	other := errors.New("other")
+	tests := []struct {
+		err            error
+		wantKind       error
+		wantConstraint string
+	}{
+		{sql.ErrNoRows, ErrNotFound, ""},
+		{context.DeadlineExceeded, ErrTimeout, ""},
+		{&pq.Error{Code: "23505", Constraint: "people_pkey"}, nil, "people_pkey"},
+		{&pq.Error{Code: "23503"}, ErrForeignKeyViolation, ""},
+		{&pq.Error{Code: "23514"}, ErrCheckViolation, ""},
+		{&pq.Error{Code: "40001"}, ErrSerialization, ""},
+		{&pq.Error{Code: "57014"}, ErrTimeout, ""},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"}, nil, "PRIMARY"},
+		{&mysql.MySQLError{Number: 1452}, ErrForeignKeyViolation, ""},
+		{&mysql.MySQLError{Number: 1213}, ErrSerialization, ""},
+		{fmt.Errorf("wrapped: %w", &pq.Error{Code: "23514"}), ErrCheckViolation, ""},
+		{other, other, ""},
+	}
+	for i, test := range tests {
+		have := classifyError(test.err)
+		if !errors.Is(have, test.err) {
+			t.Errorf("TestClassifyError()[%d] = %v, want wrapped %v.", i, have, test.err)
+		}
+
+		var unique *ErrUniqueViolation
+		switch {
+		case test.wantKind != nil && !errors.Is(have, test.wantKind):
+			t.Errorf("TestClassifyError()[%d] = %v, want %v.", i, have, test.wantKind)
+		case test.wantKind == nil && (!errors.As(have, &unique) || unique.Constraint != test.wantConstraint):
+			t.Errorf("TestClassifyError()[%d] = %v, want *ErrUniqueViolation for %q.", i, have, test.wantConstraint)
+		}
+	}
+
+	if have := classifyError(nil); have != nil {
+		t.Errorf("TestClassifyError() = %v, want nil.", have)
+	}
+}
+
+// TestTypedErrors tests that the errors returned by a Connection can be
+// inspected with errors.Is() and errors.As().
+func TestTypedErrors(t *testing.T) {
+	type Person struct {
+		ID  int32 `sql:"id" opt:"PRIMARY KEY"`
+		Age int32 `sql:"age" opt:"CHECK (age >= 0)"`
+	}
+
+	conn := createTableUnsafe("People", Person{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	people := []Person{{1, 20}, {1, 30}}
+	_, err := conn.InsertObjects("People", people)
+	var unique *ErrUniqueViolation
+	if !errors.As(err, &unique) || unique.Constraint != "people_pkey" {
+		t.Errorf("TestTypedErrors() = %v, want *ErrUniqueViolation for \"people_pkey\".", err)
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		t.Errorf("TestTypedErrors() = %v, want wrapped *pq.Error.", err)
+	}
+
+	if _, err := conn.InsertObject("People", Person{2, -1}); !errors.Is(err, ErrCheckViolation) {
+		t.Errorf("TestTypedErrors() = %v, want ErrCheckViolation.", err)
+	}
+	if err := conn.UpdateObject("People", Person{3, 40}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("TestTypedErrors() = %v, want ErrNotFound.", err)
+	}
+}
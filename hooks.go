@@ -0,0 +1,136 @@
+package structql
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// BeforeInserter is implemented by objects that prepare themselves before they
+// are inserted by InsertObject(), Upsert(), InsertObjects(), or CopyFrom().  An
+// error aborts the insertion.  For example:
+//
+//  func (p *Person) BeforeInsert() error {
+//    p.Name = strings.TrimSpace(p.Name)
+//    if p.Name == "" {
+//      return errors.New("person has no name")
+//    }
+//    return nil
+//  }
+//
+// Hooks with pointer receivers may modify the object; the modified object is
+// written to the database, but the caller only observes the modification if a
+// pointer to the object (or a slice of objects) was passed.
+type BeforeInserter interface {
+	BeforeInsert() error
+}
+
+// AfterInserter is implemented by objects that react to being inserted by
+// InsertObject(), Upsert(), or InsertObjects().  The hook receives the object
+// populated with the inserted row.  InsertObjects() rolls back its transaction
+// if the hook fails, but the other operations have already written the row, so
+// their error does not undo the insertion.
+type AfterInserter interface {
+	AfterInsert() error
+}
+
+// BeforeUpdater is implemented by objects that prepare themselves before they
+// are updated by UpdateObject() or UpdateFields().  An error aborts the update.
+type BeforeUpdater interface {
+	BeforeUpdate() error
+}
+
+// AfterUpdater is implemented by objects that react to being updated by
+// UpdateObject() or UpdateFields().  The row has already been updated when the
+// hook runs, so an error from the hook does not undo the update.
+type AfterUpdater interface {
+	AfterUpdate() error
+}
+
+// BeforeDeleter is implemented by objects that must approve their deletion by
+// DeleteObject() or HardDelete().  An error aborts the deletion.
+type BeforeDeleter interface {
+	BeforeDelete() error
+}
+
+// AfterDeleter is implemented by objects that react to being deleted by
+// DeleteObject() or HardDelete().  The row has already been deleted when the
+// hook runs, so an error from the hook does not undo the deletion.
+type AfterDeleter interface {
+	AfterDelete() error
+}
+
+// AfterSelecter is implemented by objects that complete themselves after they
+// are loaded from the database (e.g., by SelectFromWhere()).  An error aborts
+// the query.
+type AfterSelecter interface {
+	AfterSelect() error
+}
+
+// Define the names of the hooks that are invoked by runHook().
+const (
+	beforeInsert = "BeforeInsert"
+	afterInsert  = "AfterInsert"
+	beforeUpdate = "BeforeUpdate"
+	afterUpdate  = "AfterUpdate"
+	beforeDelete = "BeforeDelete"
+	afterDelete  = "AfterDelete"
+	afterSelect  = "AfterSelect"
+)
+
+// runHook invokes the hook with the given name on the provided object if the
+// object implements it.  Hooks with pointer receivers are only invoked if the
+// object is addressable (see addressable()).
+func runHook(objVal reflect.Value, name string) error {
+	recv := objVal.Interface()
+	if objVal.CanAddr() {
+		recv = objVal.Addr().Interface()
+	}
+
+	var err error
+	switch name {
+	case beforeInsert:
+		if hook, ok := recv.(BeforeInserter); ok {
+			err = hook.BeforeInsert()
+		}
+	case afterInsert:
+		if hook, ok := recv.(AfterInserter); ok {
+			err = hook.AfterInsert()
+		}
+	case beforeUpdate:
+		if hook, ok := recv.(BeforeUpdater); ok {
+			err = hook.BeforeUpdate()
+		}
+	case afterUpdate:
+		if hook, ok := recv.(AfterUpdater); ok {
+			err = hook.AfterUpdate()
+		}
+	case beforeDelete:
+		if hook, ok := recv.(BeforeDeleter); ok {
+			err = hook.BeforeDelete()
+		}
+	case afterDelete:
+		if hook, ok := recv.(AfterDeleter); ok {
+			err = hook.AfterDelete()
+		}
+	case afterSelect:
+		if hook, ok := recv.(AfterSelecter); ok {
+			err = hook.AfterSelect()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook of %s failed: %w", name, objVal.Type(), err)
+	}
+	return nil
+}
+
+// addressable returns an addressable copy of the given object unless the
+// object is already addressable, so that hooks with pointer receivers can be
+// invoked on it.
+func addressable(objVal reflect.Value) reflect.Value {
+	if objVal.CanAddr() {
+		return objVal
+	}
+	dup := reflect.New(objVal.Type()).Elem()
+	dup.Set(objVal)
+	return dup
+}
@@ -0,0 +1,161 @@
+// Package structql implements the Database structure.
+// This file contains tests for hooks.go.
+package structql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// hookedPerson is a person that normalises and validates itself with hooks.
+type hookedPerson struct {
+	ID       int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+	Name     string `sql:"name"`
+	Selected bool
+}
+
+// BeforeInsert implements the BeforeInserter interface.
+func (p *hookedPerson) BeforeInsert() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return errors.New("name is empty")
+	}
+	return nil
+}
+
+// BeforeUpdate implements the BeforeUpdater interface.
+func (p *hookedPerson) BeforeUpdate() error {
+	return p.BeforeInsert()
+}
+
+// BeforeDelete implements the BeforeDeleter interface.
+func (p hookedPerson) BeforeDelete() error {
+	if p.Name == "Admin" {
+		return errors.New("admin cannot be deleted")
+	}
+	return nil
+}
+
+// AfterSelect implements the AfterSelecter interface.
+func (p *hookedPerson) AfterSelect() error {
+	p.Selected = true
+	return nil
+}
+
+// auditedPerson is a person whose AfterInsert() hook always fails and whose
+// AfterDelete() hook records the deletion.
+type auditedPerson struct {
+	ID      int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+	Name    string `sql:"name"`
+	Deleted bool
+}
+
+// AfterInsert implements the AfterInserter interface.
+func (p *auditedPerson) AfterInsert() error {
+	return errors.New("audit log is unavailable")
+}
+
+// AfterDelete implements the AfterDeleter interface.
+func (p *auditedPerson) AfterDelete() error {
+	p.Deleted = true
+	return nil
+}
+
+// TestRunHook tests the runHook() function.
+func TestRunHook(t *testing.T) {
+	tests := []struct {
+		person   hookedPerson
+		hook     string
+		wantName string
+		wantErr  bool
+	}{
+		{hookedPerson{Name: " Bob "}, beforeInsert, "Bob", false},
+		{hookedPerson{Name: "  "}, beforeInsert, "", true},
+		{hookedPerson{Name: " Bob "}, afterInsert, " Bob ", false},
+		{hookedPerson{Name: "Admin"}, beforeDelete, "Admin", true},
+		{hookedPerson{Name: "Bob"}, beforeDelete, "Bob", false},
+	}
+	for i, test := range tests {
+		objVal := addressable(reflect.ValueOf(test.person))
+		err := runHook(objVal, test.hook)
+		haveName := objVal.Interface().(hookedPerson).Name
+		if haveName != test.wantName || (err != nil) != test.wantErr {
+			t.Errorf("TestRunHook()[%d] = (%q, %v), want (%q, error = %t).", i, haveName, err, test.wantName, test.wantErr)
+		}
+	}
+
+	// Verify that value receivers are invoked on unaddressable objects.
+	if err := runHook(reflect.ValueOf(hookedPerson{Name: "Admin"}), beforeDelete); err == nil {
+		t.Errorf("TestRunHook() = %v, want error from value receiver.", err)
+	}
+}
+
+// TestHooks tests that the hooks of an object are invoked by the operations
+// of a Connection.
+func TestHooks(t *testing.T) {
+	conn := createTableUnsafe("People", hookedPerson{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	// Verify that the BeforeInsert() hook normalises and validates the object.
+	if _, err := conn.InsertObject("People", hookedPerson{Name: " "}); err == nil {
+		t.Errorf("TestHooks() - inserted a Person without a name.")
+	}
+	people := []hookedPerson{{Name: " Admin "}, {Name: " Bob"}}
+	if _, err := conn.InsertObjects("People", people); err != nil {
+		t.Fatalf("Failed to insert People: %v.", err)
+	}
+
+	// Verify that the AfterSelect() hook completes the selected objects.
+	rows, err := conn.SelectFrom(hookedPerson{}, "People")
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("TestHooks() = %v (%v), want 2 People.", rows, err)
+	}
+	for i, row := range rows {
+		want := hookedPerson{ID: int32(i + 1), Name: people[i].Name, Selected: true}
+		if !reflect.DeepEqual(row, want) {
+			t.Errorf("TestHooks()[%d] = %v, want %v.", i, row, want)
+		}
+	}
+
+	// Verify that the BeforeUpdate() and BeforeDelete() hooks can abort.
+	if err := conn.UpdateObject("People", hookedPerson{ID: 2}); err == nil {
+		t.Errorf("TestHooks() - updated a Person without a name.")
+	}
+	if err := conn.DeleteObject("People", rows[0]); err == nil {
+		t.Errorf("TestHooks() - deleted the admin.")
+	}
+	if err := conn.DeleteObject("People", rows[1]); err != nil {
+		t.Errorf("TestHooks() - failed to delete Person: %v.", err)
+	}
+	if n, err := conn.CountRows("People"); err != nil || n != 1 {
+		t.Errorf("TestHooks() = %d (%v), want 1 row.", n, err)
+	}
+}
+
+// TestAfterHookError tests that an object is populated and its row is kept when
+// its AfterInsert() hook fails, and that the delete hooks of a pointer to an
+// object modify the object itself.
+func TestAfterHookError(t *testing.T) {
+	conn := createTableUnsafe("People", auditedPerson{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	person := auditedPerson{Name: "Bob"}
+	result, err := conn.Upsert("People", &person, OnConflict().DoNothing())
+	if err == nil {
+		t.Errorf("TestAfterHookError() = %v, want error from AfterInsert hook.", err)
+	}
+	if result.Action != Inserted || result.ID != 1 || person.ID != 1 {
+		t.Errorf("TestAfterHookError() = (%v, %d), want (inserted, 1) and populated Person %v.", result.Action, result.ID, person)
+	}
+	if n, err := conn.CountRows("People"); err != nil || n != 1 {
+		t.Errorf("TestAfterHookError() = %d (%v), want 1 row.", n, err)
+	}
+
+	if err := conn.DeleteObject("People", &person); err != nil || !person.Deleted {
+		t.Errorf("TestAfterHookError() = %v, want AfterDelete hook to mark Person %v.", err, person)
+	}
+}
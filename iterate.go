@@ -0,0 +1,155 @@
+package structql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// Iterator steps through the rows of a query one row at a time, converting each
+// row into a structure only when it is reached.  Unlike SelectFromWhere(), an
+// Iterator never holds more than one row in memory, which makes it suitable for
+// tables that are too large to load at once:
+//
+//  it, err := conn.Iterate(Person{}, "people", "age > $1", 30)
+//  if err != nil {
+//    return err
+//  }
+//  defer it.Close()
+//  for it.Next() {
+//    var person Person
+//    if err := it.Scan(&person); err != nil {
+//      return err
+//    }
+//    ...
+//  }
+//  return it.Err()
+//
+// An open Iterator occupies a database connection, so it must be closed once it
+// is no longer needed.  Exhausting the rows or encountering an error closes the
+// Iterator automatically.
+type Iterator struct {
+	// rows are the rows of the query.
+	rows *sql.Rows
+	// parser converts each row into a structure.
+	parser *rowParser
+	// current is the structure of the current row; it is invalid before the
+	// first call to Next() and after the rows are exhausted.
+	current reflect.Value
+	// count is the number of rows that have been reached.
+	count int
+	// err is the first error encountered while reading the rows.
+	err error
+}
+
+// Iterate executes a SELECT FROM WHERE query on the Connection receiver over the
+// given object type, table, and conditional and returns an Iterator over the
+// resulting rows.  As with UpdateWhere(), the condition is parameterized: the
+// arguments are referenced as $1, $2, etc.  Setting the conditional to ""
+// iterates over every row of the table.
+func (conn *Connection) Iterate(object interface{}, table string, cond string, args ...interface{}) (*Iterator, error) {
+	template := reflect.TypeOf(object)
+	if template == nil || template.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %T is not a structure", object)
+	}
+
+	// Exclude soft-deleted rows from the result.
+	info := structInfoOf(template)
+	cond = conn.excludeDeleted(table, info.cols, cond)
+
+	stmt := fmt.Sprintf("SELECT %s FROM %s;", info.selectList, table)
+	if cond != "" {
+		stmt = fmt.Sprintf("SELECT %s FROM %s WHERE %s;", info.selectList, table, cond)
+	}
+	rows, err := conn.query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query %q: %w", stmt, err)
+	}
+	return newIterator(rows, template, conn.mapping)
+}
+
+// newIterator returns an Iterator that converts the given rows into structures
+// of the given type.  The rows are closed if the Iterator cannot be created.
+func newIterator(rows *sql.Rows, template reflect.Type, mapping Mapping) (*Iterator, error) {
+	parser, err := newRowParser(rows, template, mapping)
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+	return &Iterator{rows: rows, parser: parser}, nil
+}
+
+// Next advances the Iterator receiver to the next row and reports whether such
+// a row exists.  The Iterator is closed once Next() returns false; Err() should
+// then be consulted to distinguish the end of the rows from an error.
+func (it *Iterator) Next() bool {
+	it.current = reflect.Value{}
+	if it.err != nil || !it.rows.Next() {
+		it.Close()
+		return false
+	}
+
+	current, err := it.parser.parse(it.rows)
+	if err != nil {
+		it.err = fmt.Errorf("failed to parse row %d: %w", it.count, err)
+		it.Close()
+		return false
+	}
+	it.current = current
+	it.count++
+	return true
+}
+
+// Scan copies the structure of the current row into the given destination,
+// which must be a pointer to a structure with the type of the iterated object.
+func (it *Iterator) Scan(dest interface{}) error {
+	if !it.current.IsValid() {
+		return fmt.Errorf("no current row; call Next before Scan")
+	}
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.IsNil() || destVal.Elem().Type() != it.parser.template {
+		return fmt.Errorf("destination of type %T is not a non-nil pointer to type %s", dest, it.parser.template)
+	}
+	destVal.Elem().Set(it.current)
+	return nil
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (it *Iterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	if err := it.rows.Err(); err != nil {
+		return fmt.Errorf("failed to read row %d: %w", it.count, classifyError(err))
+	}
+	return nil
+}
+
+// Close closes the Iterator receiver and releases its database connection.  It
+// is safe to call Close() more than once.
+func (it *Iterator) Close() error {
+	it.current = reflect.Value{}
+	if err := it.rows.Close(); err != nil {
+		return fmt.Errorf("failed to close rows: %w", err)
+	}
+	return nil
+}
+
+// ForEach invokes the given function with each row of a SELECT FROM WHERE query
+// on the Connection receiver as a structure with the type of the given object.
+// The rows are read one at a time as described by Iterate().  Iteration stops
+// at the first error returned by the function, and that error is returned.
+func (conn *Connection) ForEach(object interface{}, table string, cond string, fn func(interface{}) error, args ...interface{}) error {
+	it, err := conn.Iterate(object, table, cond, args...)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.Next() {
+		if err := fn(it.current.Interface()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
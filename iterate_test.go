@@ -0,0 +1,153 @@
+// Package structql implements the Database structure.
+// This file contains tests for iterate.go.
+package structql
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestIteratorScan tests the Scan() method of the Iterator type.
+func TestIteratorScan(t *testing.T) {
+	type Person struct {
+		ID   int32  `sql:"id"`
+		Name string `sql:"name"`
+	}
+	type Other struct {
+		ID int32 `sql:"id"`
+	}
+
+	db, err := sql.Open("structql-fake", "")
+	if err != nil {
+		t.Fatalf("Failed to open fake database: %v.", err)
+	}
+	defer db.Close()
+
+	person := Person{7, "Ann"}
+	tests := []struct {
+		current reflect.Value
+		dest    interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{reflect.ValueOf(person), &Person{}, person, false},
+		{reflect.Value{}, &Person{}, Person{}, true},
+		{reflect.ValueOf(person), Person{}, nil, true},
+		{reflect.ValueOf(person), (*Person)(nil), nil, true},
+		{reflect.ValueOf(person), &Other{}, Other{}, true},
+	}
+	for i, test := range tests {
+		rows, err := db.Query("SELECT")
+		if err != nil {
+			t.Fatalf("TestIteratorScan()[%d] - failed to query: %v.", i, err)
+		}
+		it, err := newIterator(rows, reflect.TypeOf(Person{}), MappingStrict)
+		if err != nil {
+			t.Fatalf("TestIteratorScan()[%d] - failed to create iterator: %v.", i, err)
+		}
+		it.current = test.current
+
+		err = it.Scan(test.dest)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestIteratorScan()[%d] = %v, want error %t.", i, err, test.wantErr)
+		}
+		if destVal := reflect.ValueOf(test.dest); test.want != nil && !reflect.DeepEqual(destVal.Elem().Interface(), test.want) {
+			t.Errorf("TestIteratorScan()[%d] = %v, want destination %v.", i, destVal.Elem().Interface(), test.want)
+		}
+		it.Close()
+	}
+}
+
+// TestIteratorEmpty tests an Iterator over a query without rows.
+func TestIteratorEmpty(t *testing.T) {
+	type Person struct {
+		ID int32 `sql:"id"`
+	}
+
+	db, err := sql.Open("structql-fake", "")
+	if err != nil {
+		t.Fatalf("Failed to open fake database: %v.", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatalf("TestIteratorEmpty() - failed to query: %v.", err)
+	}
+	it, err := newIterator(rows, reflect.TypeOf(Person{}), MappingStrict)
+	if err != nil {
+		t.Fatalf("TestIteratorEmpty() - failed to create iterator: %v.", err)
+	}
+	if it.Next() {
+		t.Errorf("TestIteratorEmpty() = true, want no rows.")
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("TestIteratorEmpty() = %v, want no error.", err)
+	}
+	if err := it.Scan(&Person{}); err == nil {
+		t.Errorf("TestIteratorEmpty() - scanned a row after the rows were exhausted.")
+	}
+	if err := it.Close(); err != nil {
+		t.Errorf("TestIteratorEmpty() = %v, want the iterator to close twice.", err)
+	}
+
+	if _, err := newIterator(rows, reflect.TypeOf(0), MappingStrict); err == nil {
+		t.Errorf("TestIteratorEmpty() - created an iterator over a non-structure type.")
+	}
+}
+
+// TestIterate tests the Iterate() and ForEach() methods of the Connection type.
+func TestIterate(t *testing.T) {
+	type Person struct {
+		ID   int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Name string `sql:"name"`
+		Age  int32  `sql:"age"`
+	}
+
+	conn := createTableUnsafe("People", Person{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	people := []Person{{1, "Ann", 20}, {2, "Bob", 30}, {3, "Cat", 40}}
+	if _, err := conn.InsertObjects("People", people); err != nil {
+		t.Fatalf("Failed to insert People: %v.", err)
+	}
+
+	it, err := conn.Iterate(Person{}, "People", "age >= $1 ORDER BY id", 30)
+	if err != nil {
+		t.Fatalf("TestIterate() - failed to iterate: %v.", err)
+	}
+	have := []Person{}
+	for it.Next() {
+		var person Person
+		if err := it.Scan(&person); err != nil {
+			t.Fatalf("TestIterate() - failed to scan: %v.", err)
+		}
+		have = append(have, person)
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("TestIterate() = %v, want no error.", err)
+	}
+	if want := people[1:]; !reflect.DeepEqual(have, want) {
+		t.Errorf("TestIterate() = %v, want people %v.", have, want)
+	}
+
+	// Verify that ForEach stops at the first error.
+	stop := errors.New("stop")
+	count := 0
+	err = conn.ForEach(Person{}, "People", "", func(obj interface{}) error {
+		if _, ok := obj.(Person); !ok {
+			t.Errorf("TestIterate() = %T, want type Person.", obj)
+		}
+		count++
+		if count == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 2 {
+		t.Errorf("TestIterate() = %d (%v), want 2 rows and the callback error.", count, err)
+	}
+}
@@ -0,0 +1,78 @@
+package structql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/inflowml/logger"
+)
+
+// Mapping controls how mismatches between structures and tables are reported.
+// A mismatch is a structure field without an "sql" tag or a supported column
+// type, or a result column without a corresponding structure field.
+type Mapping int
+
+// Define the supported Mapping modes.
+const (
+	// MappingWarn logs a warning for every mismatch.  This is the default.
+	MappingWarn Mapping = iota
+	// MappingStrict fails the operation with an error that wraps ErrUnmapped.
+	MappingStrict
+	// MappingLenient silently ignores mismatches.
+	MappingLenient
+)
+
+// ErrUnmapped is wrapped by the errors that report mismatches between
+// structures and tables in the MappingStrict mode.
+var ErrUnmapped = errors.New("unmapped field or column")
+
+// String returns the name of the Mapping receiver.
+func (m Mapping) String() string {
+	switch m {
+	case MappingStrict:
+		return "strict"
+	case MappingLenient:
+		return "lenient"
+	}
+	return "warn"
+}
+
+// report reports the mismatch described by the given format and arguments
+// according to the Mapping receiver.  An error is only returned in the
+// MappingStrict mode.
+func (m Mapping) report(format string, args ...interface{}) error {
+	switch m {
+	case MappingStrict:
+		return fmt.Errorf(format+": %w", append(args, ErrUnmapped)...)
+	case MappingLenient:
+		return nil
+	}
+	logger.Warning(format+".", args...)
+	return nil
+}
+
+// reportUntagged reports each of the given untagged fields of the provided
+// structure according to the Mapping receiver.
+func (m Mapping) reportUntagged(structure interface{}, untagged []string) error {
+	for _, name := range untagged {
+		if err := m.report("Field %q in structure %s does not have an SQL column tag", name, structure); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithMapping returns a view of the Connection receiver that reports mismatches
+// between structures and tables according to the given Mapping.  For example,
+// a test suite can catch schema drift with:
+//
+//  people, err := conn.WithMapping(structql.MappingStrict).SelectFrom(Person{}, "people")
+//
+// The default Mapping of a Connection is set by its ConnectionConfig.  The view
+// shares the underlying database connection with the receiver, so closing
+// either one closes both.
+func (conn *Connection) WithMapping(m Mapping) *Connection {
+	view := *conn
+	view.mapping = m
+	return &view
+}
@@ -0,0 +1,78 @@
+// Package structql implements the Database structure.
+// This file contains tests for mapping.go.
+package structql
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestMappingReport tests the Mapping.report() method.
+func TestMappingReport(t *testing.T) {
+	tests := []struct {
+		mapping Mapping
+		wantErr bool
+	}{
+		{MappingWarn, false},
+		{MappingStrict, true},
+		{MappingLenient, false},
+	}
+	for i, test := range tests {
+		err := test.mapping.report("Column %q is unmapped", "age")
+		if (err != nil) != test.wantErr || (err != nil && !errors.Is(err, ErrUnmapped)) {
+			t.Errorf("TestMappingReport()[%d] = %v, want error = %t.", i, err, test.wantErr)
+		}
+	}
+}
+
+// TestStrictCreateTable tests that (*Connection).CreateTableFromObject() rejects
+// unmapped fields in the MappingStrict mode.
+func TestStrictCreateTable(t *testing.T) {
+	type Untagged struct {
+		ID   int32 `sql:"id" opt:"PRIMARY KEY"`
+		Note string
+	}
+	type Untyped struct {
+		ID   int32          `sql:"id" opt:"PRIMARY KEY"`
+		Tags map[string]int `sql:"tags"`
+	}
+
+	conn := (&Connection{}).WithMapping(MappingStrict)
+	for i, object := range []interface{}{Untagged{}, Untyped{}} {
+		if err := conn.CreateTableFromObject("Things", object); !errors.Is(err, ErrUnmapped) {
+			t.Errorf("TestStrictCreateTable()[%d] = %v, want ErrUnmapped.", i, err)
+		}
+	}
+}
+
+// TestStrictSelect tests that unmapped result columns fail a query in the
+// MappingStrict mode.
+func TestStrictSelect(t *testing.T) {
+	type Person struct {
+		ID   int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Name string `sql:"name"`
+		Age  int32  `sql:"age"`
+	}
+	type Name struct {
+		Name string `sql:"name"`
+	}
+
+	conn := createTableUnsafe("People", Person{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	if _, err := conn.InsertObject("People", Person{Name: "Adam", Age: 10}); err != nil {
+		t.Fatalf("Failed to insert Person: %v.", err)
+	}
+
+	strict := conn.WithMapping(MappingStrict)
+	if rows, err := strict.SelectFrom(Name{}, "People"); err != nil || len(rows) != 1 {
+		t.Errorf("TestStrictSelect() = %v (%v), want 1 Name.", rows, err)
+	}
+	if _, err := strict.OldestEntry(Name{}, "People", "id"); !errors.Is(err, ErrUnmapped) {
+		t.Errorf("TestStrictSelect() = %v, want ErrUnmapped.", err)
+	}
+	if _, err := conn.WithMapping(MappingLenient).OldestEntry(Name{}, "People", "id"); err != nil {
+		t.Errorf("TestStrictSelect() = %v, want no error.", err)
+	}
+}
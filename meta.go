@@ -0,0 +1,64 @@
+package structql
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structInfo holds the reflected metadata of a structure type that is shared by
+// every operation on objects of that type.  A structInfo is immutable once it
+// has been constructed.
+type structInfo struct {
+	// cols are the columns of the structure type (see getColumns()).
+	cols []column
+	// untagged are the paths of the fields that do not map to a column.
+	untagged []string
+	// byName maps the name of each column to the column.
+	byName map[string]column
+	// selectList is the comma-separated list of the column names.
+	selectList string
+	// keys are the primary key columns of the structure type, if any.
+	keys []column
+	// keyErr is the error that prevented the primary key from being derived.
+	keyErr error
+	// rules maps the name of each column to the rules in its "validate" tag.
+	rules map[string][]rule
+	// ruleErrs maps the name of each column to the error that prevented its
+	// "validate" tag from being parsed.
+	ruleErrs map[string]error
+}
+
+// structInfos caches the structInfo of each structure type by its reflect.Type.
+var structInfos sync.Map
+
+// structInfoOf returns the metadata of the given structure type, deriving and
+// caching it on first use.  It is safe for concurrent use.
+func structInfoOf(template reflect.Type) *structInfo {
+	if info, ok := structInfos.Load(template); ok {
+		return info.(*structInfo)
+	}
+
+	info := &structInfo{
+		byName:   map[string]column{},
+		rules:    map[string][]rule{},
+		ruleErrs: map[string]error{},
+	}
+	info.cols, info.untagged = walkColumns(template)
+	names := make([]string, len(info.cols))
+	for i, col := range info.cols {
+		names[i] = col.name
+		info.byName[col.name] = col
+		if rules, err := parseRules(col); err != nil {
+			info.ruleErrs[col.name] = err
+		} else if len(rules) > 0 {
+			info.rules[col.name] = rules
+		}
+	}
+	info.selectList = strings.Join(names, ", ")
+	info.keys, info.keyErr = findPrimaryKey(template, info.cols)
+
+	// Another goroutine may have stored the same metadata in the meantime.
+	actual, _ := structInfos.LoadOrStore(template, info)
+	return actual.(*structInfo)
+}
@@ -0,0 +1,118 @@
+// Package structql implements the Database structure.
+// This file contains tests for meta.go.
+package structql
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// benchPerson is a representative structure for the benchmarks below.
+type benchPerson struct {
+	ID      int32     `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+	Name    string    `sql:"name" validate:"required,max=64"`
+	Email   string    `sql:"email" validate:"regex=^[^@]+@[^@]+$"`
+	Age     int32     `sql:"age" validate:"min=0,max=150"`
+	Mass    float64   `sql:"mass"`
+	Created time.Time `sql:"created_at" auto:"create"`
+	Address struct {
+		City    string `sql:"city"`
+		Country string `sql:"country"`
+	} `prefix:"addr_"`
+}
+
+// TestStructInfoOf tests the structInfoOf() function.
+func TestStructInfoOf(t *testing.T) {
+	typ := reflect.TypeOf(benchPerson{})
+
+	// Derive the metadata concurrently; every goroutine must observe the same value.
+	infos := make([]*structInfo, 8)
+	var wg sync.WaitGroup
+	for i := range infos {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			infos[i] = structInfoOf(typ)
+		}(i)
+	}
+	wg.Wait()
+	for i, info := range infos {
+		if info != infos[0] {
+			t.Errorf("TestStructInfoOf()[%d] = %p, want cached %p.", i, info, infos[0])
+		}
+	}
+
+	info := infos[0]
+	wantList := "id, name, email, age, mass, created_at, addr_city, addr_country"
+	if info.selectList != wantList {
+		t.Errorf("TestStructInfoOf() = %q, want select list %q.", info.selectList, wantList)
+	}
+	if len(info.keys) != 1 || info.keys[0].name != "id" || info.keyErr != nil {
+		t.Errorf("TestStructInfoOf() = (%v, %v), want key \"id\".", info.keys, info.keyErr)
+	}
+	if len(info.rules["name"]) != 2 || len(info.rules["mass"]) != 0 {
+		t.Errorf("TestStructInfoOf() = %v, want rules of \"name\" only.", info.rules)
+	}
+
+	// Verify that appending to the returned columns does not corrupt the cache.
+	cols, _ := getColumns(typ)
+	_ = append(cols, column{name: "extra"})
+	if have, _ := getColumns(typ); !reflect.DeepEqual(have, info.cols) {
+		t.Errorf("TestStructInfoOf() = %v, want unchanged columns %v.", have, info.cols)
+	}
+}
+
+// BenchmarkWalkColumns measures the cost of deriving the columns of a structure
+// without the metadata cache.
+func BenchmarkWalkColumns(b *testing.B) {
+	typ := reflect.TypeOf(benchPerson{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cols, _ := walkColumns(typ)
+		findPrimaryKey(typ, cols)
+	}
+}
+
+// BenchmarkGetColumns measures the cost of looking up the columns and primary
+// key of a structure in the metadata cache.
+func BenchmarkGetColumns(b *testing.B) {
+	typ := reflect.TypeOf(benchPerson{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		getColumns(typ)
+		primaryKey(typ)
+	}
+}
+
+// BenchmarkValidateObject measures the cost of validating an object with the
+// cached validation rules.
+func BenchmarkValidateObject(b *testing.B) {
+	person := benchPerson{Name: "Adam", Email: "adam@example.com", Age: 30}
+	objVal := reflect.ValueOf(person)
+	cols, _ := getColumns(objVal.Type())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := validateObject(objVal, cols); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkScanRow measures the cost of scanning a row into a new object, as
+// parseResponse() does for every row of a result.
+func BenchmarkScanRow(b *testing.B) {
+	typ := reflect.TypeOf(benchPerson{})
+	row := []interface{}{int64(1), []byte("Adam"), []byte("adam@example.com"), int64(30), float64(80.5), time.Now(), []byte("Toronto"), []byte("Canada")}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		info := structInfoOf(typ)
+		vessel := reflect.New(typ).Elem()
+		for j, col := range info.cols {
+			if err := newFieldScanner(vessel, col).Scan(row[j]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -5,58 +5,71 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
-
-	"github.com/inflowml/logger"
 )
 
 // SelectFrom executes a SELECT FROM query on the Connection receiver over the
-// given object type and table.
-func (conn *Connection) SelectFrom(object interface{}, table string) ([]interface{}, error) {
-	return conn.executeSelect(object, table, "")
+// given object type and table.  The rows may be sorted, limited, and made
+// distinct with QueryOptions:
+//
+//  people, err := conn.SelectFrom(Person{}, "people", OrderBy(Desc("age")), Limit(10))
+func (conn *Connection) SelectFrom(object interface{}, table string, opts ...QueryOption) ([]interface{}, error) {
+	return conn.executeSelect(object, table, "", newQueryOptions(opts))
 }
 
 // SelectFromWhere executes a SELECT FROM WHERE query on the Connection receiver
 // over the given object type, table, and conditional.  Additional arguments are
-// substituted into the conditional in a style similar to printf().
+// substituted into the conditional in a style similar to printf(), except for
+// QueryOptions, which are applied as in SelectFrom():
+//
+//  people, err := conn.SelectFromWhere(Person{}, "people", "age > %d", 30, OrderBy(Asc("name")), Offset(20))
 func (conn *Connection) SelectFromWhere(object interface{}, table string, cond string, args ...interface{}) ([]interface{}, error) {
-	return conn.executeSelect(object, table, cond, args...)
+	args, opts := splitQueryOptions(args)
+	return conn.executeSelect(object, table, cond, opts, args...)
 }
 
 // executeSelect executes a SELECT FROM WHERE query on the Connection receiver
-// over the given object, table, and conditional.  Setting the conditional
-// to "" indicates that no conditional is desired.  Additional arguments are
-// substituted into the conditional in a style similar to printf().
-func (conn *Connection) executeSelect(object interface{}, table string, cond string, args ...interface{}) ([]interface{}, error) {
+// over the given object, table, and conditional with the clauses of the given
+// queryOptions.  Setting the conditional to "" indicates that no conditional is
+// desired.  Additional arguments are substituted into the conditional in a
+// style similar to printf().
+func (conn *Connection) executeSelect(object interface{}, table string, cond string, opts queryOptions, args ...interface{}) ([]interface{}, error) {
 	// TODO: SQL Sanitization
 	template := reflect.TypeOf(object)
-
-	// Construct a slice that holds the SQL column name of each object field.
-	cols := make([]string, 0, template.NumField())
-	for i := 0; i < template.NumField(); i++ {
-		field := template.Field(i)
-		if col, ok := field.Tag.Lookup("sql"); ok {
-			cols = append(cols, col)
-		}
+	if template == nil || template.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %T is not a structure", object)
 	}
 
-	// Format the columns into a comma-separated list.
-	colJoin := strings.Join(cols, ", ")
+	// Look up the comma-separated list of the SQL column names of the object fields.
+	info := structInfoOf(template)
+	fields, colJoin := info.cols, info.selectList
 
-	// Translate the columns, table, and conditional into an SQL statement.
-	stmt := fmt.Sprintf("SELECT %s FROM %s;", colJoin, table)
-	if cond != "" {
-		stmt = fmt.Sprintf("SELECT %s FROM %s WHERE %s;", colJoin, table, cond)
-		stmt = fmt.Sprintf(stmt, args...)
+	// Exclude soft-deleted rows from the result.
+	literal := cond != ""
+	if literal {
+		cond = fmt.Sprintf(cond, args...)
 	}
+	cond = conn.excludeDeleted(table, fields, cond)
 
-	// Execute the query on the SQL database.
-	rows, err := conn.query(stmt)
+	// Translate the columns, table, conditional, and options into an SQL statement.
+	stmt, err := conn.selectStmt(colJoin, table, cond, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query %q: %v", stmt, err)
+		return nil, err
+	}
+
+	// Execute the query on the SQL database.  Queries with a formatted
+	// conditional bypass the statement cache.
+	var rows *sql.Rows
+	if literal {
+		rows, err = conn.queryLiteral(stmt)
+	} else {
+		rows, err = conn.query(stmt)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query %q: %w", stmt, err)
 	}
 
 	// Parse the rows from the query into a slice of Go objects based on the prototype.
-	return parseResponse(rows, object)
+	return parseResponse(rows, object, conn.mapping)
 }
 
 //SelectForUpdate is to be used in conjuction with Lock and Unlock to facilitate row locking
@@ -65,17 +78,12 @@ func (conn *Connection) SelectForUpdate(object interface{}, table string, cond s
 	// TODO: SQL Sanitization
 	template := reflect.TypeOf(object)
 
-	// Construct a slice that holds the SQL column name of each object field.
-	cols := make([]string, 0, template.NumField())
-	for i := 0; i < template.NumField(); i++ {
-		field := template.Field(i)
-		if col, ok := field.Tag.Lookup("sql"); ok {
-			cols = append(cols, col)
-		}
-	}
+	// Look up the comma-separated list of the SQL column names of the object fields.
+	info := structInfoOf(template)
+	fields, colJoin := info.cols, info.selectList
 
-	// Format the columns into a comma-separated list.
-	colJoin := strings.Join(cols, ", ")
+	// Exclude soft-deleted rows from the result.
+	cond = conn.excludeDeleted(table, fields, cond)
 
 	// Translate the columns, table, and conditional into an SQL statement.
 	stmt := fmt.Sprintf("SELECT %s FROM %s;", colJoin, table)
@@ -84,166 +92,397 @@ func (conn *Connection) SelectForUpdate(object interface{}, table string, cond s
 		stmt = fmt.Sprintf(stmt, args...)
 	}
 
-	// Execute the query on the SQL database.
-	rows, err := conn.query(stmt)
+	// Execute the query on the SQL database.  Queries with a formatted
+	// conditional bypass the statement cache.
+	var rows *sql.Rows
+	var err error
+	if cond != "" {
+		rows, err = conn.queryLiteral(stmt)
+	} else {
+		rows, err = conn.query(stmt)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query %q: %v", stmt, err)
+		return nil, fmt.Errorf("failed to execute query %q: %w", stmt, err)
 	}
 
 	// Parse the rows from the query into a slice of Go objects based on the prototype.
-	return parseResponse(rows, object)
+	return parseResponse(rows, object, conn.mapping)
 }
 
 // InsertObject inserts the given object into the specified table and returns
-// the record ID of the inserted row.
+// the record ID of the inserted row.  The record ID is the value of the primary
+// key column if the key consists of a single integer column and 0 otherwise.
+// If the object is a pointer to a structure, the structure is populated with
+// the inserted row so that values generated by the database (e.g., SERIAL IDs
+// and DEFAULT values) become available to the caller.  Rows that conflict with
+// an existing row are ignored and yield a record ID of 0; use Upsert() to
+// distinguish conflicts or to update the existing row.
 func (conn *Connection) InsertObject(table string, object interface{}) (int, error) {
+	result, err := conn.Upsert(table, object, OnConflict().DoNothing())
+	return result.ID, err
+}
+
+// UpdateObject updates the given object in the specified table.  The row to
+// update is identified by the primary key columns of the object, and every
+// other column is written unless the object tracks its changes (see Tracker).
+// An error is returned if no row is updated; for objects with a version
+// column, this error is an *ErrStaleObject, and otherwise it wraps ErrNotFound.
+// If the object is a pointer to a structure, its version is advanced to match
+// the updated row.
+func (conn *Connection) UpdateObject(table string, object interface{}) error {
+	return conn.updateObject(table, object, nil)
+}
+
+// UpdateFields updates the given columns of the object in the specified table,
+// leaving every other column of the row untouched.  For example:
+//
+//  err := conn.UpdateFields("people", person, "name", "age")
+//
+// As with UpdateObject(), the row is identified by the primary key columns of
+// the object, which therefore cannot be updated.  Requesting a key, version,
+// soft-delete, or generated (e.g., auto) column is an error, since UpdateObject()
+// never writes these columns either.  Both methods invoke the update
+// hooks of the object (see BeforeUpdater and AfterUpdater) and validate the
+// written columns (see ValidationError).
+func (conn *Connection) UpdateFields(table string, object interface{}, columns ...string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("no columns were given to update")
+	}
+	return conn.updateObject(table, object, columns)
+}
+
+// updateObject updates the given columns of the object in the specified table.
+// If no columns are given, every non-key column is updated.  If the object is
+// a pointer to a structure with a Tracker, the Tracker is refreshed.
+func (conn *Connection) updateObject(table string, object interface{}, columns []string) error {
 	// Extract the underlying type and value of the object.
-	objType := reflect.TypeOf(object)
-	objValue := reflect.ValueOf(object)
+	objTyp := reflect.TypeOf(object)
+	objVal := reflect.ValueOf(object)
+	if objTyp != nil && objTyp.Kind() == reflect.Ptr && !objVal.IsNil() {
+		objTyp = objTyp.Elem()
+		objVal = objVal.Elem()
+	}
 
 	// Ensure the given object is a structure.
-	if objType.Kind() != reflect.Struct {
-		return 0, fmt.Errorf("type %T is not a structure", object)
+	if objTyp == nil || objTyp.Kind() != reflect.Struct {
+		return fmt.Errorf("type %T is not a structure", object)
 	}
 
-	// Cache the number of fields in the object; this value is used a few times.
-	numFields := objType.NumField()
+	// Locate the primary key of the object.
+	keys, err := primaryKey(objTyp)
+	if err != nil {
+		return err
+	}
 
-	// Construct a slice that holds the SQL column names of object fields.
-	cols := make([]string, 0, numFields)
-	// Construct a slice that holds the PostreSQL backreferences of object fields.
-	refs := make([]string, 0, numFields)
-	// Construct a slice that holds the values of object fields.
-	vals := make([]interface{}, 0, numFields)
+	// Let the object prepare itself for the update.
+	objVal = addressable(objVal)
+	if err := runHook(objVal, beforeUpdate); err != nil {
+		return err
+	}
 
-	// Append an element to each slice for every SQL field in the object.
-	for i := 0; i < numFields; i++ {
-		// Extract the type and value of the current field.
-		fieldType := objType.Field(i)
-		fieldValue := objValue.Field(i)
-
-		// Derive the SQL column name corresponding to the current field.
-		col, ok := fieldType.Tag.Lookup("sql")
-		if !ok {
-			logger.Warning("Field %q in structure %T does not have an SQL column tag.", fieldType.Name, object)
-			continue
-		}
+	// Derive the columns of the object, including those of nested structures.
+	fields, untagged := getColumns(objTyp)
+	if err := conn.mapping.reportUntagged(objTyp, untagged); err != nil {
+		return err
+	}
 
-		// Skip the current field if it has a SERIAL type.
-		typ := fieldType.Tag.Get("typ")
-		if strings.Contains(strings.ToUpper(typ), "SERIAL") {
-			continue
+	// Verify that each of the requested columns can be updated.
+	requested := make(map[string]bool, len(columns))
+	for _, name := range columns {
+		requested[name] = true
+	}
+	for _, field := range fields {
+		if requested[field.name] {
+			if reason := unwritableReason(keys, field); reason != "" {
+				return fmt.Errorf("column %q cannot be updated since it %s", field.name, reason)
+			}
 		}
-
-		// Let the PostgreSQL driver handle the formatting of the value.
-		val := fieldValue.Interface()
-
-		// The PostgreSQL backreference format is the same as the regex format.
-		ref := fmt.Sprintf("$%d", len(refs)+1)
-
-		// Update the column, backreference, and value slices.
-		cols = append(cols, col)
-		refs = append(refs, ref)
-		vals = append(vals, val)
+		delete(requested, field.name)
 	}
-
-	// Format the columns and backreferences into comma-separated lists.
-	colList := strings.Join(cols, ", ")
-	refList := strings.Join(refs, ", ")
-
-	// Declare an integer to hold the record ID returned by the INSERT statement.
-	var id int
-
-	// Insert the object into the specified table and return the record ID.
-	stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING RETURNING id;", table, colList, refList)
-	row := conn.queryRow(stmt, vals...)
-	err := row.Scan(&id)
-	if err == sql.ErrNoRows {
-		return 0, nil
+	for name := range requested {
+		return fmt.Errorf("structure %s does not have a field for column %q", objTyp, name)
 	}
-	return id, err
-}
-
-// UpdateObject updates the given object in the specified table.
-func (conn *Connection) UpdateObject(table string, object interface{}) error {
-	// Extract the underlying type and value of the object.
-	objTyp := reflect.TypeOf(object)
-	objVal := reflect.ValueOf(object)
 
-	// Cache the number of fields in the object; this value is used a few times.
-	numFields := objTyp.NumField()
+	// Locate the version column of the object, if any.
+	version, versioned := versionColumn(fields)
 
 	// Construct a slice that holds the SET clause entries of the UPDATE command.
-	sets := make([]string, 0, numFields)
+	sets := make([]string, 0, len(fields))
 	// Construct a slice that holds the values of object fields.
-	vals := make([]interface{}, 0, numFields)
-
-	// Declare an integer to hold the backreference index of the ID column.
-	var id int
+	vals := make([]interface{}, 0, len(fields))
+	// Construct a slice that holds the written columns.
+	written := make([]column, 0, len(fields))
 
 	// Append an element to each slice for every SQL field in the object.
-	for i := 0; i < numFields; i++ {
-		// Extract the type and value of the current field.
-		fieldTyp := objTyp.Field(i)
-		fieldVal := objVal.Field(i)
-
-		// Derive the SQL column name corresponding to the current field.
-		col, ok := fieldTyp.Tag.Lookup("sql")
-		if !ok {
-			logger.Warning("Field %q in structure %T does not have an SQL column tag.", fieldTyp.Name, object)
+	for _, field := range fields {
+		// Key columns identify the row and are matched in the WHERE clause instead.
+		if isKey(keys, field) {
+			continue
+		}
+
+		// The version column is incremented once the other columns are known.
+		if versioned && field.name == version.name {
+			continue
+		}
+
+		// Generated columns are never written; auto columns are set below.
+		if isGenerated(field) {
+			continue
+		}
+
+		// The soft-delete column is only written by DeleteObject().
+		if field.opts.has("softdelete") {
+			continue
+		}
+
+		// Skip the columns that were not requested.
+		if len(columns) > 0 && !containsString(columns, field.name) {
 			continue
 		}
 
 		// Let the PostgreSQL driver handle the formatting of the value.
-		val := fieldVal.Interface()
+		val, err := encodeValue(field, objVal.FieldByIndex(field.index))
+		if err != nil {
+			return err
+		}
+
+		// Skip the columns that have not changed since the object was loaded.
+		if len(columns) == 0 && !changed(objVal, field, val) {
+			continue
+		}
 
 		// Create a PostgreSQL SET clause entry with a backreference to the field value.
-		ref := len(vals) + 1
-		set := fmt.Sprintf("%s = $%d", col, ref)
+		set := fmt.Sprintf("%s = $%d", field.name, len(vals)+1)
 
 		// Update the SET clause and value slices.
 		sets = append(sets, set)
 		vals = append(vals, val)
+		written = append(written, field)
+	}
 
-		// Set the backreference index of the ID column.
-		if col == "id" {
-			id = ref
+	// Validate the written columns of the object.
+	if err := validateObject(objVal, written); err != nil {
+		return err
+	}
+
+	if len(sets) == 0 {
+		if _, ok := trackerIndex(objTyp); ok {
+			return nil
 		}
+		return fmt.Errorf("structure %s does not have any non-key columns to update", objTyp)
+	}
+
+	// Set the timestamps of the auto columns.
+	sets = append(sets, autoUpdates(fields)...)
+
+	// Increment the version of a versioned object.
+	if versioned {
+		sets = append(sets, fmt.Sprintf("%s = %s + 1", version.name, version.name))
 	}
 
 	// Format the SET clause as a comma-separated list of SET clause entries.
 	setList := strings.Join(sets, ", ")
 
+	// Match the row using every key column.
+	where, vals, err := keyCondition(objVal, keys, vals)
+	if err != nil {
+		return err
+	}
+
+	// Only update a versioned row if the object holds its current version.
+	var current int64
+	if versioned {
+		current = toInt64(objVal.FieldByIndex(version.index))
+		vals = append(vals, current)
+		where += fmt.Sprintf(" AND %s = $%d", version.name, len(vals))
+	}
+
 	// Update the object in the specified table.  For more information, see
 	// https://www.postgresql.org/docs/current/sql-update.html.
-	stmt := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d;", table, setList, id)
-	_, err := conn.exec(stmt, vals...)
-	return err
+	stmt := fmt.Sprintf("UPDATE %s SET %s WHERE %s;", table, setList, where)
+	result, err := conn.exec(stmt, vals...)
+	if err != nil {
+		return err
+	}
+
+	// Verify that a row was updated.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get the number of updated rows: %w", err)
+	}
+	if affected == 0 && versioned {
+		return &ErrStaleObject{table, current}
+	}
+	if affected == 0 {
+		return fmt.Errorf("no row in table %q matches the key of the object: %w", table, ErrNotFound)
+	}
+
+	if objVal.CanSet() {
+		// Advance the version of the object to that of its row.
+		if versioned {
+			field := objVal.FieldByIndex(version.index)
+			field.Set(reflect.ValueOf(current + 1).Convert(field.Type()))
+		}
+
+		// Remember the written values of a tracked object.
+		track(objVal, fields)
+	}
+	return runHook(objVal, afterUpdate)
 }
 
-// DeleteObject deletes the given object from the specified table.
+// DeleteObject deletes the given object from the specified table.  The row to
+// delete is identified by the primary key columns of the object.  If the object
+// declares a soft-delete column (see softDeleteColumn()), the row is retained
+// and the column is set to the current time instead; use HardDelete() to remove
+// such a row permanently.  If the object is a pointer to a structure, its
+// delete hooks (see BeforeDeleter and AfterDeleter) receive the structure
+// itself rather than a copy.
 func (conn *Connection) DeleteObject(table string, object interface{}) error {
+	return conn.deleteObject(table, object, false)
+}
+
+// deleteObject deletes the given object from the specified table.  Objects with
+// a soft-delete column are only marked as deleted unless hard is set.
+func (conn *Connection) deleteObject(table string, object interface{}, hard bool) error {
 	// Extract the underlying type and value of the object.
 	objTyp := reflect.TypeOf(object)
 	objVal := reflect.ValueOf(object)
+	if objTyp != nil && objTyp.Kind() == reflect.Ptr && !objVal.IsNil() {
+		objTyp = objTyp.Elem()
+		objVal = objVal.Elem()
+	}
+
+	// Ensure the given object is a structure.
+	if objTyp == nil || objTyp.Kind() != reflect.Struct {
+		return fmt.Errorf("type %T is not a structure", object)
+	}
 
-	// isID reports whether the field with the provided name is an ID column.
-	isID := func(name string) bool {
-		field, _ := objTyp.FieldByName(name)
-		return field.Tag.Get("sql") == "id"
+	// Locate the primary key of the object.
+	keys, err := primaryKey(objTyp)
+	if err != nil {
+		return err
+	}
+
+	// Let the object approve its deletion.
+	objVal = addressable(objVal)
+	if err := runHook(objVal, beforeDelete); err != nil {
+		return err
 	}
 
-	// Retrieve the object ID.
-	id := objVal.FieldByNameFunc(isID)
-	if reflect.DeepEqual(id, reflect.Value{}) {
-		return fmt.Errorf("structure %T does not have an ID field", object)
+	// Match the row using every key column.
+	where, vals, err := keyCondition(objVal, keys, nil)
+	if err != nil {
+		return err
 	}
 
 	// Delete the object from the specified table.  For more information, see
 	// https://www.postgresql.org/docs/current/sql-delete.html.
-	stmt := fmt.Sprintf("DELETE FROM %s WHERE id = $1;", table)
-	_, err := conn.exec(stmt, id.Interface())
-	return err
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE %s;", table, where)
+
+	// Mark a soft-deletable object as deleted unless it already is.
+	fields, _ := getColumns(objTyp)
+	if deleted, ok := conn.deletedColumn(table, fields); ok && !hard {
+		stmt = fmt.Sprintf("UPDATE %s SET %s = CURRENT_TIMESTAMP WHERE %s AND %s IS NULL;", table, deleted, where, deleted)
+	}
+
+	if _, err := conn.exec(stmt, vals...); err != nil {
+		return err
+	}
+	return runHook(objVal, afterDelete)
+}
+
+// keyCondition constructs a WHERE clause that matches each of the given key
+// columns of an object against its value.  The key values are appended to the
+// provided slice of values and backreferenced accordingly.
+func keyCondition(objVal reflect.Value, keys []column, vals []interface{}) (string, []interface{}, error) {
+	conds := make([]string, 0, len(keys))
+	for _, key := range keys {
+		val, err := encodeValue(key, objVal.FieldByIndex(key.index))
+		if err != nil {
+			return "", nil, err
+		}
+		vals = append(vals, val)
+		conds = append(conds, fmt.Sprintf("%s = $%d", key.name, len(vals)))
+	}
+	return strings.Join(conds, " AND "), vals, nil
+}
+
+// containsString reports whether the given slice of strings contains s.
+func containsString(slice []string, s string) bool {
+	for _, elem := range slice {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
+
+// isKey reports whether the given column is one of the provided key columns.
+func isKey(keys []column, col column) bool {
+	for _, key := range keys {
+		if key.name == col.name {
+			return true
+		}
+	}
+	return false
+}
+
+// unwritableReason explains why the given column of an object with the provided
+// key columns is never written by an UPDATE command, or returns "" if it is.
+func unwritableReason(keys []column, col column) string {
+	switch {
+	case isKey(keys, col):
+		return "is part of the primary key"
+	case col.opts.has("version"):
+		return "is a version column, which is incremented automatically"
+	case col.opts.has("softdelete"):
+		return "is a soft-delete column, which is only written by DeleteObject()"
+	case isGenerated(col):
+		return "is generated by the database"
+	}
+	return ""
+}
+
+// isGenerated reports whether the value of the given column is always
+// generated by the database, which is the case for columns with a SERIAL type,
+// GENERATED columns, and auto columns.
+func isGenerated(col column) bool {
+	return strings.Contains(strings.ToUpper(col.field.Tag.Get("typ")), "SERIAL") ||
+		strings.Contains(strings.ToUpper(col.field.Tag.Get("opt")), "GENERATED") ||
+		autoMode(col) != ""
+}
+
+// usesDefault reports whether the DEFAULT value of the given column is inserted
+// in place of the given field value.  This is the case if the "sql" tag of the
+// column carries the "default" option and the field holds its zero value; an
+// explicit zero value is otherwise inserted like any other value.
+func usesDefault(col column, val reflect.Value) bool {
+	return col.opts.has("default") && isZero(val)
+}
+
+// isZero reports whether the given value is the zero value of its type.
+func isZero(val reflect.Value) bool {
+	return reflect.DeepEqual(val.Interface(), reflect.Zero(val.Type()).Interface())
+}
+
+// recordID returns the name of the column that holds the record ID of a row
+// with the given key columns.  The boolean result reports whether such a
+// column exists; this is the case when the key is a single integer column.
+func recordID(keys []column) (string, bool) {
+	if len(keys) != 1 || !isInteger(keys[0].field.Type) {
+		return "", false
+	}
+	return keys[0].name, true
+}
+
+// isInteger reports whether the given type is a signed or unsigned integer.
+func isInteger(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
 }
 
 //Lock will execute the SQL BEGIN command which aids in concurrent operations
@@ -251,7 +490,7 @@ func (conn *Connection) DeleteObject(table string, object interface{}) error {
 func (conn *Connection) Lock() error {
 	_, err := conn.db.Exec("BEGIN;")
 	if err != nil {
-		return fmt.Errorf("Failed to execute BEGIN statement: %v", err)
+		return fmt.Errorf("Failed to execute BEGIN statement: %w", err)
 	}
 	return nil
 }
@@ -260,7 +499,7 @@ func (conn *Connection) Lock() error {
 func (conn *Connection) Unlock() error {
 	_, err := conn.db.Exec("END;")
 	if err != nil {
-		return fmt.Errorf("Failed to execute BEGIN statement: %v", err)
+		return fmt.Errorf("Failed to execute BEGIN statement: %w", err)
 	}
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 )
 
 // TestSelectFromWhere tests the (*Connection).SelectFromWhere() method.
@@ -134,7 +135,7 @@ func TestInsertObject(t *testing.T) {
 			continue
 		}
 
-		people, err := parseResponse(rows, Person{})
+		people, err := parseResponse(rows, Person{}, MappingWarn)
 		if err != nil {
 			t.Errorf("TestInsertObject()[%d] - failed to parse response: %v.", i, err)
 			continue
@@ -153,6 +154,84 @@ func TestInsertObject(t *testing.T) {
 	}
 }
 
+// TestInsertObjectPointer tests that (*Connection).InsertObject() populates a
+// structure with the values generated by the database.
+func TestInsertObjectPointer(t *testing.T) {
+	type Account struct {
+		ID      int64     `sql:"id" typ:"BIGSERIAL" opt:"PRIMARY KEY"`
+		Token   string    `sql:"token,default" typ:"TEXT" opt:"DEFAULT md5('structql')"`
+		Name    string    `sql:"name"`
+		Upper   string    `sql:"upper" typ:"TEXT" opt:"GENERATED ALWAYS AS (upper(name)) STORED"`
+		Created time.Time `sql:"created_at,tz,default" opt:"DEFAULT now()"`
+	}
+
+	conn := createTableUnsafe("Accounts", Account{})
+	defer conn.Close()
+	defer conn.DropTable("Accounts")
+
+	account := Account{Name: "ada"}
+	id, err := conn.InsertObject("Accounts", &account)
+	if err != nil {
+		t.Fatalf("TestInsertObjectPointer() - failed to insert Account: %v.", err)
+	}
+
+	if id != 1 || account.ID != 1 {
+		t.Errorf("TestInsertObjectPointer() = (%d, %d), want record ID 1.", id, account.ID)
+	}
+	if len(account.Token) != 32 {
+		t.Errorf("TestInsertObjectPointer() = %q, want default token.", account.Token)
+	}
+	if account.Upper != "ADA" {
+		t.Errorf("TestInsertObjectPointer() = %q, want generated value %q.", account.Upper, "ADA")
+	}
+	if account.Created.IsZero() {
+		t.Errorf("TestInsertObjectPointer() = %v, want default timestamp.", account.Created)
+	}
+
+	// Verify that explicit values take precedence over defaults.
+	token := Account{Name: "bob", Token: "secret"}
+	if _, err := conn.InsertObject("Accounts", &token); err != nil {
+		t.Fatalf("TestInsertObjectPointer() - failed to insert Account: %v.", err)
+	}
+	if token.Token != "secret" || token.ID != 2 {
+		t.Errorf("TestInsertObjectPointer() = %v, want token %q and record ID 2.", token, "secret")
+	}
+}
+
+// TestInsertDefault tests that explicit zero values are inserted into columns
+// with a DEFAULT value unless the columns carry the "default" option.
+func TestInsertDefault(t *testing.T) {
+	type Flag struct {
+		ID     int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Active bool   `sql:"active" opt:"DEFAULT true"`
+		Label  string `sql:"label,default" typ:"TEXT" opt:"DEFAULT 'none'"`
+	}
+
+	conn := createTableUnsafe("Flags", Flag{})
+	defer conn.Close()
+	defer conn.DropTable("Flags")
+
+	flag := Flag{Active: false}
+	if _, err := conn.InsertObject("Flags", &flag); err != nil {
+		t.Fatalf("TestInsertDefault() - failed to insert Flag: %v.", err)
+	}
+	if want := (Flag{1, false, "none"}); flag != want {
+		t.Errorf("TestInsertDefault() = %v, want inserted flag %v.", flag, want)
+	}
+
+	if _, err := conn.InsertObjects("Flags", []Flag{{Active: false}, {Active: true, Label: "set"}}); err != nil {
+		t.Fatalf("TestInsertDefault() - failed to insert Flags: %v.", err)
+	}
+	have, err := conn.SelectFromWhere(Flag{}, "Flags", "TRUE ORDER BY id")
+	if err != nil {
+		t.Fatalf("TestInsertDefault() - failed to select Flags: %v.", err)
+	}
+	want := []interface{}{Flag{1, false, "none"}, Flag{2, false, "none"}, Flag{3, true, "set"}}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("TestInsertDefault() = %v, want flags %v.", have, want)
+	}
+}
+
 // TestUpdateObject tests the (*Connection).UpdateObject() method.
 func TestUpdateObject(t *testing.T) {
 	type Person struct {
@@ -261,6 +340,94 @@ func TestDeleteObject(t *testing.T) {
 			t.Errorf("TestDeleteObject()[%d] = %v, want Pizza %v.", i, havePizzas, test.wantPizzas)
 		}
 	}
+
+	// Verify that nil objects are rejected.
+	for i, object := range []interface{}{nil, (*Pizza)(nil)} {
+		if err := conn.DeleteObject("Pizza", object); err == nil {
+			t.Errorf("TestDeleteObject()[nil %d] - deleted a nil object.", i)
+		}
+	}
+}
+
+// TestNestedObject tests the mapping of embedded and nested structures by the
+// (*Connection).InsertObject() and (*Connection).SelectFrom() methods.
+func TestNestedObject(t *testing.T) {
+	type Audit struct {
+		Author string `sql:"author"`
+	}
+	type Address struct {
+		City   string `sql:"city"`
+		Street string `sql:"street"`
+	}
+	type Person struct {
+		Audit
+		ID   int32   `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Name string  `sql:"name"`
+		Addr Address `sql:"addr" prefix:"addr_"`
+	}
+
+	conn := createTableUnsafe("People", Person{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	want := Person{Audit{"admin"}, 1, "Ada", Address{"London", "Baker Street"}}
+	if _, err := conn.InsertObject("People", want); err != nil {
+		t.Fatalf("TestNestedObject() - failed to insert Person %v: %v.", want, err)
+	}
+
+	people, err := conn.SelectFromWhere(Person{}, "People", "addr_city = 'London'")
+	if err != nil {
+		t.Fatalf("TestNestedObject() - failed to select Person: %v.", err)
+	}
+	if len(people) != 1 {
+		t.Fatalf("TestNestedObject() = %d, want 1 Person.", len(people))
+	}
+	if have := people[0].(Person); !reflect.DeepEqual(have, want) {
+		t.Errorf("TestNestedObject() = %v, want Person %v.", have, want)
+	}
+}
+
+// TestTypedObject tests the round trip of NUMERIC, DATE, TIMESTAMPTZ, and
+// INTERVAL columns through the (*Connection).InsertObject() and
+// (*Connection).SelectFrom() methods.
+func TestTypedObject(t *testing.T) {
+	type Shipment struct {
+		ID       int32         `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Price    Decimal       `sql:"price" typ:"NUMERIC(12,2)"`
+		Shipped  Date          `sql:"shipped"`
+		Arrived  time.Time     `sql:"arrived,tz"`
+		Duration time.Duration `sql:"duration"`
+	}
+
+	conn := createTableUnsafe("Shipments", Shipment{})
+	defer conn.Close()
+	defer conn.DropTable("Shipments")
+
+	arrived := time.Date(2020, time.March, 4, 5, 6, 7, 0, time.FixedZone("EST", -5*60*60))
+	want := Shipment{
+		ID:       1,
+		Price:    NewDecimal(1999, 2),
+		Shipped:  Date{2020, time.March, 1},
+		Arrived:  arrived.UTC(),
+		Duration: 3*24*time.Hour + 90*time.Minute,
+	}
+
+	insert := want
+	insert.Arrived = arrived
+	if _, err := conn.InsertObject("Shipments", insert); err != nil {
+		t.Fatalf("TestTypedObject() - failed to insert Shipment %v: %v.", insert, err)
+	}
+
+	shipments, err := conn.SelectFrom(Shipment{}, "Shipments")
+	if err != nil {
+		t.Fatalf("TestTypedObject() - failed to select Shipment: %v.", err)
+	}
+	if len(shipments) != 1 {
+		t.Fatalf("TestTypedObject() = %d, want 1 Shipment.", len(shipments))
+	}
+	if have := shipments[0].(Shipment); !reflect.DeepEqual(have, want) {
+		t.Errorf("TestTypedObject() = %v, want Shipment %v.", have, want)
+	}
 }
 
 // createTableUnsafe constructs a database Connection and creates a table with
@@ -0,0 +1,294 @@
+package structql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PageRequest describes a page of rows for Page().
+type PageRequest struct {
+	// After is the token of the page that follows the returned page, or "" for
+	// the first page.
+	After string
+	// Before is the token of the page that precedes the returned page.  At most
+	// one of After and Before may be set.
+	Before string
+	// Limit is the maximum number of rows in the page.
+	Limit int
+	// OrderBy is the sort key of the pages.  The primary key of the object is
+	// appended to break ties, so the rows are sorted by their primary key if
+	// OrderBy is empty.
+	OrderBy []Order
+	// Where is an optional parameterized condition that filters the rows; its
+	// arguments are referenced as $1, $2, etc.
+	Where string
+	// Args are the arguments of the Where condition.
+	Args []interface{}
+}
+
+// Page is a page of rows returned by Page().
+type Page struct {
+	// Items are the rows of the page as structures with the type of the object.
+	Items []interface{}
+	// Next is the token of the following page, or "" if this is the last page.
+	Next string
+	// Previous is the token of the preceding page, or "" if this is the first
+	// page.
+	Previous string
+}
+
+// pageKey is a column of the sort key of a page.
+type pageKey struct {
+	col  column
+	desc bool
+}
+
+// pageToken is the decoded form of a page token.
+type pageToken struct {
+	// Order identifies the sort key that the token was issued for.
+	Order string `json:"o"`
+	// Values are the sort key values of the row at the edge of a page.
+	Values []json.RawMessage `json:"v"`
+}
+
+// Page returns a page of the rows of the given table using keyset (or "seek")
+// pagination.  Rather than skipping rows with OFFSET, the page continues from
+// the sort key of the row at the edge of the previous page:
+//
+//  page, err := conn.Page(Person{}, "people", PageRequest{Limit: 50, OrderBy: []Order{Desc("age")}})
+//  ...
+//  next, err := conn.Page(Person{}, "people", PageRequest{After: page.Next, Limit: 50, OrderBy: []Order{Desc("age")}})
+//
+// This remains fast on large tables provided that an index covers the sort key,
+// and rows that are inserted or deleted between requests do not shift pages.
+// The tokens are opaque and are only valid for the sort key they were issued
+// with.  The columns of the sort key must not contain NULL values.
+func (conn *Connection) Page(object interface{}, table string, req PageRequest) (Page, error) {
+	template := reflect.TypeOf(object)
+	if template == nil || template.Kind() != reflect.Struct {
+		return Page{}, fmt.Errorf("type %T is not a structure", object)
+	}
+	if req.Limit <= 0 {
+		return Page{}, fmt.Errorf("page limit %d is not positive", req.Limit)
+	}
+	if req.After != "" && req.Before != "" {
+		return Page{}, fmt.Errorf("a page cannot be requested both after and before a token")
+	}
+
+	keys, err := pageKeys(template, req.OrderBy)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// Pages before a token are selected in reverse order and then restored.
+	backward := req.Before != ""
+	token := req.After
+	if backward {
+		token = req.Before
+	}
+	var vals []interface{}
+	if token != "" {
+		if vals, err = decodePageToken(token, keys); err != nil {
+			return Page{}, err
+		}
+	}
+
+	info := structInfoOf(template)
+	stmt, args := pageStmt(table, info.selectList, keys, conn.excludeDeleted(table, info.cols, req.Where), req.Args, vals, backward, req.Limit+1)
+	rows, err := conn.query(stmt, args...)
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to execute query %q: %w", stmt, err)
+	}
+	items, err := parseResponse(rows, object, conn.mapping)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// The extra row reveals whether another page lies in the direction of travel.
+	more := len(items) > req.Limit
+	if more {
+		items = items[:req.Limit]
+	}
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	page := Page{Items: items}
+	if len(items) == 0 {
+		return page, nil
+	}
+	if backward || more {
+		if page.Next, err = encodePageToken(reflect.ValueOf(items[len(items)-1]), keys); err != nil {
+			return Page{}, err
+		}
+	}
+	if (backward && more) || (!backward && token != "") {
+		if page.Previous, err = encodePageToken(reflect.ValueOf(items[0]), keys); err != nil {
+			return Page{}, err
+		}
+	}
+	return page, nil
+}
+
+// pageKeys returns the sort key of the pages of the given structure type with
+// the provided order.  The primary key columns that the order does not mention
+// are appended in ascending order.
+func pageKeys(template reflect.Type, orders []Order) ([]pageKey, error) {
+	info := structInfoOf(template)
+	keys := []pageKey{}
+	seen := map[string]bool{}
+	for _, order := range orders {
+		col, ok := info.byName[order.Column]
+		if !ok {
+			return nil, fmt.Errorf("no field in structure %s is tagged with SQL column %q", template, order.Column)
+		}
+		if seen[col.name] {
+			return nil, fmt.Errorf("column %q appears more than once in the page order", col.name)
+		}
+		if order.Nulls != NullsDefault {
+			return nil, fmt.Errorf("column %q of the page order cannot place NULL values", col.name)
+		}
+		seen[col.name] = true
+		keys = append(keys, pageKey{col, order.Desc})
+	}
+
+	pks, err := primaryKey(template)
+	if err != nil {
+		return nil, err
+	}
+	for _, pk := range pks {
+		if !seen[pk.name] {
+			keys = append(keys, pageKey{pk, false})
+		}
+	}
+	return keys, nil
+}
+
+// pageStmt constructs the SELECT statement of a page.  The values of the sort
+// key of the row at the edge of the previous page are backreferenced after the
+// arguments of the condition; if there are no values, the first page is
+// selected.  A backward page is selected in reverse order.
+func pageStmt(table string, selectList string, keys []pageKey, cond string, args []interface{}, vals []interface{}, backward bool, limit int) (string, []interface{}) {
+	args = append([]interface{}{}, args...)
+	conds := []string{}
+	if cond != "" {
+		conds = append(conds, "("+cond+")")
+	}
+	if len(vals) > 0 {
+		refs := make([]string, len(vals))
+		for i, val := range vals {
+			args = append(args, val)
+			refs[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conds = append(conds, seekCondition(keys, refs, backward))
+	}
+
+	orders := make([]string, len(keys))
+	for i, key := range keys {
+		orders[i] = Order{Column: key.col.name, Desc: key.desc != backward}.String()
+	}
+
+	stmt := fmt.Sprintf("SELECT %s FROM %s", selectList, table)
+	if len(conds) > 0 {
+		stmt += " WHERE " + strings.Join(conds, " AND ")
+	}
+	stmt += fmt.Sprintf(" ORDER BY %s LIMIT %d;", strings.Join(orders, ", "), limit)
+	return stmt, args
+}
+
+// seekCondition returns the condition that selects the rows which follow (or,
+// if backward is set, precede) the sort key values with the given references.
+// A row comparison is used when every column is sorted in the same direction,
+// since it is able to use a composite index directly.
+func seekCondition(keys []pageKey, refs []string, backward bool) string {
+	op := func(key pageKey) string {
+		if key.desc != backward {
+			return "<"
+		}
+		return ">"
+	}
+
+	uniform := true
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.col.name
+		uniform = uniform && key.desc == keys[0].desc
+	}
+	if len(keys) == 1 {
+		return fmt.Sprintf("%s %s %s", names[0], op(keys[0]), refs[0])
+	}
+	if uniform {
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(names, ", "), op(keys[0]), strings.Join(refs, ", "))
+	}
+
+	// Expand the comparison column by column: (a > $1 OR (a = $1 AND b < $2)).
+	last := len(keys) - 1
+	cond := fmt.Sprintf("%s %s %s", names[last], op(keys[last]), refs[last])
+	for i := last - 1; i >= 0; i-- {
+		cond = fmt.Sprintf("(%s %s %s OR (%s = %s AND %s))", names[i], op(keys[i]), refs[i], names[i], refs[i], cond)
+	}
+	return cond
+}
+
+// orderSignature identifies the given sort key in a page token.
+func orderSignature(keys []pageKey) string {
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		terms[i] = key.col.name
+		if key.desc {
+			terms[i] = "-" + key.col.name
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+// encodePageToken returns the token that identifies the position of the given
+// structure under the provided sort key.
+func encodePageToken(objVal reflect.Value, keys []pageKey) (string, error) {
+	token := pageToken{Order: orderSignature(keys), Values: make([]json.RawMessage, len(keys))}
+	for i, key := range keys {
+		val, err := json.Marshal(objVal.FieldByIndex(key.col.index).Interface())
+		if err != nil {
+			return "", fmt.Errorf("failed to encode column %q in page token: %w", key.col.name, err)
+		}
+		token.Values[i] = val
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode page token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodePageToken returns the sort key values of the given token in a form that
+// is suitable for the database driver.
+func decodePageToken(s string, keys []pageKey) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed page token %q: %w", s, err)
+	}
+	var token pageToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("malformed page token %q: %w", s, err)
+	}
+	if signature := orderSignature(keys); token.Order != signature || len(token.Values) != len(keys) {
+		return nil, fmt.Errorf("page token was issued for order %q, not %q", token.Order, signature)
+	}
+
+	vals := make([]interface{}, len(keys))
+	for i, key := range keys {
+		val := reflect.New(key.col.field.Type)
+		if err := json.Unmarshal(token.Values[i], val.Interface()); err != nil {
+			return nil, fmt.Errorf("malformed value of column %q in page token: %w", key.col.name, err)
+		}
+		if vals[i], err = encodeValue(key.col, val.Elem()); err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
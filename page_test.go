@@ -0,0 +1,226 @@
+// Package structql implements the Database structure.
+// This file contains tests for page.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// pagedPerson is a structure that is paged in the tests below.
+type pagedPerson struct {
+	ID      int32     `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+	Name    string    `sql:"name"`
+	Age     int32     `sql:"age"`
+	Created time.Time `sql:"created"`
+}
+
+// pagedInvoice is a structure with a Decimal sort key that is paged in the
+// tests below.
+type pagedInvoice struct {
+	ID    int32   `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+	Total Decimal `sql:"total"`
+}
+
+// TestPageStmt tests the pageStmt() function.
+func TestPageStmt(t *testing.T) {
+	template := reflect.TypeOf(pagedPerson{})
+
+	tests := []struct {
+		orders   []Order
+		cond     string
+		args     []interface{}
+		vals     []interface{}
+		backward bool
+		wantStmt string
+		wantArgs []interface{}
+	}{
+		{
+			nil,
+			"",
+			nil,
+			nil,
+			false,
+			"SELECT id, name, age, created FROM people ORDER BY id ASC LIMIT 11;",
+			[]interface{}{},
+		}, {
+			nil,
+			"",
+			nil,
+			[]interface{}{7},
+			true,
+			"SELECT id, name, age, created FROM people WHERE id < $1 ORDER BY id DESC LIMIT 11;",
+			[]interface{}{7},
+		}, {
+			[]Order{Asc("age")},
+			"name <> $1",
+			[]interface{}{"Ann"},
+			[]interface{}{30, 7},
+			false,
+			"SELECT id, name, age, created FROM people WHERE (name <> $1) AND (age, id) > ($2, $3) ORDER BY age ASC, id ASC LIMIT 11;",
+			[]interface{}{"Ann", 30, 7},
+		}, {
+			[]Order{Desc("age"), Asc("name")},
+			"",
+			nil,
+			[]interface{}{30, "Bob", 7},
+			false,
+			"SELECT id, name, age, created FROM people WHERE (age < $1 OR (age = $1 AND (name > $2 OR (name = $2 AND id > $3)))) ORDER BY age DESC, name ASC, id ASC LIMIT 11;",
+			[]interface{}{30, "Bob", 7},
+		}, {
+			[]Order{Desc("age"), Desc("id")},
+			"",
+			nil,
+			[]interface{}{30, 7},
+			true,
+			"SELECT id, name, age, created FROM people WHERE (age, id) > ($1, $2) ORDER BY age ASC, id ASC LIMIT 11;",
+			[]interface{}{30, 7},
+		},
+	}
+	for i, test := range tests {
+		keys, err := pageKeys(template, test.orders)
+		if err != nil {
+			t.Errorf("TestPageStmt()[%d] = %v, want no error.", i, err)
+			continue
+		}
+		haveStmt, haveArgs := pageStmt("people", "id, name, age, created", keys, test.cond, test.args, test.vals, test.backward, 11)
+		if haveStmt != test.wantStmt {
+			t.Errorf("TestPageStmt()[%d] = %q, want statement %q.", i, haveStmt, test.wantStmt)
+		}
+		if !reflect.DeepEqual(haveArgs, test.wantArgs) {
+			t.Errorf("TestPageStmt()[%d] = %v, want arguments %v.", i, haveArgs, test.wantArgs)
+		}
+	}
+}
+
+// TestPageKeys tests the pageKeys() function.
+func TestPageKeys(t *testing.T) {
+	template := reflect.TypeOf(pagedPerson{})
+
+	tests := []struct {
+		orders  []Order
+		want    string
+		wantErr bool
+	}{
+		{nil, "id", false},
+		{[]Order{Desc("age")}, "-age,id", false},
+		{[]Order{Desc("id"), Asc("name")}, "-id,name", false},
+		{[]Order{Asc("missing")}, "", true},
+		{[]Order{Asc("age"), Desc("age")}, "", true},
+	}
+	for i, test := range tests {
+		keys, err := pageKeys(template, test.orders)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestPageKeys()[%d] = %v, want error %t.", i, err, test.wantErr)
+			continue
+		}
+		if have := orderSignature(keys); err == nil && have != test.want {
+			t.Errorf("TestPageKeys()[%d] = %q, want sort key %q.", i, have, test.want)
+		}
+	}
+}
+
+// TestPageToken tests the encodePageToken() and decodePageToken() functions.
+func TestPageToken(t *testing.T) {
+	template := reflect.TypeOf(pagedPerson{})
+	keys, _ := pageKeys(template, []Order{Desc("created"), Asc("name")})
+	other, _ := pageKeys(template, []Order{Asc("created")})
+
+	person := pagedPerson{7, "Ann", 30, time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)}
+	token, err := encodePageToken(reflect.ValueOf(person), keys)
+	if err != nil {
+		t.Fatalf("TestPageToken() - failed to encode token: %v.", err)
+	}
+
+	tests := []struct {
+		token   string
+		keys    []pageKey
+		want    []interface{}
+		wantErr bool
+	}{
+		{token, keys, []interface{}{person.Created, "Ann", int32(7)}, false},
+		{token, other, nil, true},
+		{"not a token", keys, nil, true},
+		{"e30", keys, nil, true},
+	}
+	for i, test := range tests {
+		have, err := decodePageToken(test.token, test.keys)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestPageToken()[%d] = %v, want error %t.", i, err, test.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("TestPageToken()[%d] = %v, want values %v.", i, have, test.want)
+		}
+	}
+
+	// Verify that a Decimal sort key survives the round trip exactly.
+	invoiceKeys, _ := pageKeys(reflect.TypeOf(pagedInvoice{}), []Order{Desc("total")})
+	invoice := pagedInvoice{3, NewDecimal(123456789012345678, 4)}
+	token, err = encodePageToken(reflect.ValueOf(invoice), invoiceKeys)
+	if err != nil {
+		t.Fatalf("TestPageToken() - failed to encode Decimal token: %v.", err)
+	}
+	have, err := decodePageToken(token, invoiceKeys)
+	if want := []interface{}{invoice.Total, int32(3)}; err != nil || !reflect.DeepEqual(have, want) {
+		t.Errorf("TestPageToken() = %v (%v), want Decimal values %v.", have, err, want)
+	}
+}
+
+// TestPage tests the Page() method of the Connection type.
+func TestPage(t *testing.T) {
+	conn := createTableUnsafe("People", pagedPerson{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	people := []pagedPerson{{1, "Ann", 30, created}, {2, "Bob", 20, created}, {3, "Cat", 30, created}, {4, "Dan", 40, created}, {5, "Eve", 20, created}}
+	if _, err := conn.InsertObjects("People", people); err != nil {
+		t.Fatalf("Failed to insert People: %v.", err)
+	}
+
+	// Walk forward through the pages, sorted by descending age, then back again.
+	orders := []Order{Desc("age")}
+	wants := [][]int32{{4, 1}, {3, 2}, {5}}
+	var page Page
+	for i, want := range wants {
+		var err error
+		page, err = conn.Page(pagedPerson{}, "People", PageRequest{After: page.Next, Limit: 2, OrderBy: orders})
+		if err != nil {
+			t.Fatalf("TestPage()[%d] - failed to get page: %v.", i, err)
+		}
+		if have := pageIDs(page); !reflect.DeepEqual(have, want) {
+			t.Errorf("TestPage()[%d] = %v, want IDs %v.", i, have, want)
+		}
+		if (page.Next == "") != (i == len(wants)-1) || (page.Previous == "") != (i == 0) {
+			t.Errorf("TestPage()[%d] = %q, %q, want tokens only where pages exist.", i, page.Next, page.Previous)
+		}
+	}
+
+	page, err := conn.Page(pagedPerson{}, "People", PageRequest{Before: page.Previous, Limit: 2, OrderBy: orders})
+	if err != nil {
+		t.Fatalf("TestPage() - failed to get previous page: %v.", err)
+	}
+	if have, want := pageIDs(page), []int32{3, 2}; !reflect.DeepEqual(have, want) {
+		t.Errorf("TestPage() = %v, want IDs %v.", have, want)
+	}
+
+	// Verify that a filtered page references its arguments before the sort key.
+	page, err = conn.Page(pagedPerson{}, "People", PageRequest{After: page.Previous, Limit: 5, OrderBy: orders, Where: "age < $1", Args: []interface{}{40}})
+	if err != nil {
+		t.Fatalf("TestPage() - failed to get filtered page: %v.", err)
+	}
+	if have, want := pageIDs(page), []int32{3, 2, 5}; !reflect.DeepEqual(have, want) {
+		t.Errorf("TestPage() = %v, want IDs %v.", have, want)
+	}
+}
+
+// pageIDs returns the IDs of the people in the given page.
+func pageIDs(page Page) []int32 {
+	ids := []int32{}
+	for _, item := range page.Items {
+		ids = append(ids, item.(pagedPerson).ID)
+	}
+	return ids
+}
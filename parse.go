@@ -4,8 +4,8 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
-
-	"github.com/inflowml/logger"
+	"strconv"
+	"time"
 )
 
 // parseResponse parses the given SQL rows into a slice of structures with the
@@ -21,86 +21,279 @@ import (
 // a column in the database table.  This enables an *sql.Rows object to be
 // converted into a slice of Person structures with the following code:
 //
-//  people, err := parseResponse(rows, Person{})
+//  people, err := parseResponse(rows, Person{}, MappingWarn)
 //
 // Given that people has type []interface{}, it is necessary to cast an entry of
 // people into a Person object before accessing a member of that Person object.
-func parseResponse(rows *sql.Rows, object interface{}) ([]interface{}, error) {
-	template := reflect.TypeOf(object)
+//
+// The rows are closed once they have been parsed.  Columns without a matching
+// field are skipped and reported according to the given Mapping, and NULL
+// values leave their fields with the zero value of their type unless the field
+// type implements sql.Scanner.  An error that identifies the column and field is
+// returned if a value cannot be converted to the type of its field.
+func parseResponse(rows *sql.Rows, object interface{}, mapping Mapping) ([]interface{}, error) {
+	defer rows.Close()
 
-	// Verify that the object is a structure.
-	if template.Kind() != reflect.Struct {
-		return []interface{}{}, fmt.Errorf("type %T is not a structure", object)
+	parser, err := newRowParser(rows, reflect.TypeOf(object), mapping)
+	if err != nil {
+		return []interface{}{}, err
 	}
 
-	// Construct a map that associates the name of a column with the name of a field.
-	ctfMap := map[string]string{}
+	// Construct a slice to hold the converted entries of each row.
+	vessels := []interface{}{}
 
-	// Populate the map column-to-field map using the template.
-	for i := 0; i < template.NumField(); i++ {
-		field := template.Field(i)
-		if col, ok := field.Tag.Lookup("sql"); ok {
-			ctfMap[col] = field.Name
+	// Loop over the rows.
+	for rows.Next() {
+		vessel, err := parser.parse(rows)
+		if err != nil {
+			return []interface{}{}, fmt.Errorf("failed to parse row %d: %w", len(vessels), err)
 		}
+		vessels = append(vessels, vessel.Interface())
 	}
+	if err := rows.Err(); err != nil {
+		return []interface{}{}, fmt.Errorf("failed to read row %d: %w", len(vessels), classifyError(err))
+	}
+	return vessels, nil
+}
+
+// rowParser converts the rows of a result into structures of a given type one
+// row at a time.
+type rowParser struct {
+	// template is the structure type of the parsed rows.
+	template reflect.Type
+	// info is the metadata of the structure type.
+	info *structInfo
+	// colNames are the names of the columns of the result.
+	colNames []string
+}
+
+// newRowParser returns a rowParser for the given rows and structure type.  The
+// result columns without a matching field are reported according to the given
+// Mapping.
+func newRowParser(rows *sql.Rows, template reflect.Type, mapping Mapping) (*rowParser, error) {
+	// Verify that the object is a structure.
+	if template == nil || template.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %v is not a structure", template)
+	}
+
+	// Look up the map that associates the name of a column with a field.
+	info := structInfoOf(template)
 
-	// Get the names and types of the columns.
+	// Get the names of the columns.
 	colNames, err := rows.Columns()
 	if err != nil {
-		return []interface{}{}, fmt.Errorf("failed to get column names: %v", err)
+		return nil, fmt.Errorf("failed to get column names: %w", err)
 	}
-	colTypes, err := rows.ColumnTypes()
-	if err != nil {
-		return []interface{}{}, fmt.Errorf("failed to get column types: %v", err)
-	}
-
-	// Construct a slice of suitable arguments to (*sql.Rows).Scan().
-	entries := []interface{}{}
-	for _, colType := range colTypes {
-		var scanType reflect.Type
-
-		// The PostgreSQL library does not support floating-point column types.
-		// See: https://github.com/lib/pq/issues/761
-		switch colType.DatabaseTypeName() {
-		case "FLOAT4":
-			scanType = reflect.TypeOf(float32(0))
-		case "FLOAT8":
-			scanType = reflect.TypeOf(float64(0))
-		default:
-			scanType = colType.ScanType()
+	for _, colName := range colNames {
+		if _, ok := info.byName[colName]; !ok {
+			if err := mapping.report("No field in structure %s is tagged with SQL column %q", template, colName); err != nil {
+				return nil, err
+			}
 		}
+	}
+	return &rowParser{template, info, colNames}, nil
+}
 
-		entry := reflect.New(scanType).Interface()
-		entries = append(entries, entry)
+// parse scans the current row into a new structure and returns it.  The
+// AfterSelect() hook of the structure is invoked, if any.
+func (p *rowParser) parse(rows *sql.Rows) (reflect.Value, error) {
+	// Construct a vessel and scan the current row directly into its fields.
+	vessel := reflect.New(p.template).Elem()
+	dests := make([]interface{}, len(p.colNames))
+	for i, colName := range p.colNames {
+		dests[i] = new(discard)
+		if col, ok := p.info.byName[colName]; ok {
+			dests[i] = newFieldScanner(vessel, col)
+		}
+	}
+	if err := rows.Scan(dests...); err != nil {
+		return vessel, err
 	}
 
-	// Construct a slice to hold the converted entries of each row.
-	vessels := []interface{}{}
+	track(vessel, p.info.cols)
+	if err := runHook(vessel, afterSelect); err != nil {
+		return vessel, err
+	}
+	return vessel, nil
+}
 
-	// Loop over the rows.
-	for rows.Next() {
-		// Scan the current row into the entry slice.
-		rows.Scan(entries...)
-
-		// Construct a vessel to hold the entries.
-		vessel := reflect.New(template).Elem()
-
-		// Loop over the entries.
-		for i := range entries {
-			// Find the column and field name associated with the current entry.
-			colName := colNames[i]
-			fieldName, ok := ctfMap[colName]
-			if !ok {
-				logger.Warning("No field in structure %T is tagged with SQL column %q.", template, colName)
-				continue
+// fieldScanner scans the value of a column directly into a structure field.
+type fieldScanner struct {
+	// col is the column of the scanned value.
+	col column
+	// field is the structure field that receives the value.
+	field reflect.Value
+}
+
+// newFieldScanner returns a scan destination for the value of the given column
+// that stores the value in the corresponding field of the vessel.
+func newFieldScanner(vessel reflect.Value, col column) *fieldScanner {
+	return &fieldScanner{col, vessel.FieldByIndex(col.index)}
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *fieldScanner) Scan(src interface{}) error {
+	// Decode values of types that require custom decoding with their scanners.
+	if dest, ok := newScanDest(s.col); ok {
+		if err := dest.(sql.Scanner).Scan(src); err != nil {
+			return fmt.Errorf("failed to decode column %q into field %s: %w", s.col.name, s.col.field.Name, err)
+		}
+		s.field.Set(decodeValue(reflect.ValueOf(dest).Elem(), s.field.Type()))
+		return nil
+	}
+
+	val, err := convertValue(src, s.field.Type())
+	if err != nil {
+		return fmt.Errorf("failed to convert column %q into field %s: %w", s.col.name, s.col.field.Name, err)
+	}
+	s.field.Set(val)
+	return nil
+}
+
+// discard is a scan destination that ignores the value of a column.
+type discard struct{}
+
+// Scan implements the sql.Scanner interface.
+func (*discard) Scan(interface{}) error {
+	return nil
+}
+
+// convertValue converts the given value from a database driver into a value of
+// the given type.  NULL values yield the zero value of the type, integers are
+// checked for overflow, times are normalised to UTC so that TIMESTAMP and
+// TIMESTAMPTZ columns yield comparable values, and textual values (as returned
+// by the MySQL driver) are parsed into numbers, booleans, and times.
+func convertValue(src interface{}, typ reflect.Type) (reflect.Value, error) {
+	val := reflect.New(typ).Elem()
+	kind := typ.Kind()
+	if src == nil {
+		return val, nil
+	}
+
+	// Copy byte slices since the driver may reuse their memory.
+	if b, ok := src.([]byte); ok {
+		if kind == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			val.SetBytes(append([]byte{}, b...))
+			return val, nil
+		}
+		src = string(b)
+	}
+
+	switch src := src.(type) {
+	case string:
+		if err := parseValue(val, src); err != nil {
+			return val, err
+		}
+		return val, nil
+	case time.Time:
+		if typ == timeType {
+			return reflect.ValueOf(src.UTC()), nil
+		}
+	case int64:
+		switch {
+		case isSigned(kind):
+			if val.OverflowInt(src) {
+				return val, fmt.Errorf("value %d overflows type %s", src, typ)
+			}
+			val.SetInt(src)
+			return val, nil
+		case isInteger(typ):
+			if src < 0 || val.OverflowUint(uint64(src)) {
+				return val, fmt.Errorf("value %d overflows type %s", src, typ)
+			}
+			val.SetUint(uint64(src))
+			return val, nil
+		case kind == reflect.Float32 || kind == reflect.Float64:
+			val.SetFloat(float64(src))
+			return val, nil
+		case kind == reflect.Bool:
+			val.SetBool(src != 0)
+			return val, nil
+		}
+	case float64:
+		if kind == reflect.Float32 || kind == reflect.Float64 {
+			if val.OverflowFloat(src) {
+				return val, fmt.Errorf("value %g overflows type %s", src, typ)
 			}
+			val.SetFloat(src)
+			return val, nil
+		}
+	case bool:
+		if kind == reflect.Bool {
+			val.SetBool(src)
+			return val, nil
+		}
+	}
 
-			// Populate a field from the vessel with the contents of the entry.
-			field := vessel.FieldByName(fieldName)
-			entry := reflect.ValueOf(entries[i]).Elem()
-			field.Set(entry)
+	// Fall back on a conversion between types of the same kind.
+	srcVal := reflect.ValueOf(src)
+	if srcVal.Kind() == kind && srcVal.Type().ConvertibleTo(typ) {
+		return srcVal.Convert(typ), nil
+	}
+	return val, fmt.Errorf("cannot convert type %T to type %s", src, typ)
+}
+
+// textTimeLayouts are the layouts of times that are returned as text.
+var textTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// parseValue parses the given text into the provided value according to its
+// kind.
+func parseValue(val reflect.Value, s string) error {
+	typ := val.Type()
+	kind := typ.Kind()
+	switch {
+	case kind == reflect.String:
+		val.SetString(s)
+	case kind == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		val.SetBytes([]byte(s))
+	case isSigned(kind):
+		n, err := strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as type %s: %w", s, typ, err)
 		}
-		vessels = append(vessels, vessel.Interface())
+		val.SetInt(n)
+	case isInteger(typ):
+		n, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as type %s: %w", s, typ, err)
+		}
+		val.SetUint(n)
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		f, err := strconv.ParseFloat(s, typ.Bits())
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as type %s: %w", s, typ, err)
+		}
+		val.SetFloat(f)
+	case kind == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as type %s: %w", s, typ, err)
+		}
+		val.SetBool(b)
+	case typ == timeType:
+		for _, layout := range textTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				val.Set(reflect.ValueOf(t.UTC()))
+				return nil
+			}
+		}
+		return fmt.Errorf("failed to parse %q as a time", s)
+	default:
+		return fmt.Errorf("cannot convert text to type %s", typ)
 	}
-	return vessels, nil
+	return nil
+}
+
+// isSigned reports whether the given kind is a signed integer kind.
+func isSigned(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
 }
@@ -5,7 +5,9 @@ package structql
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestParseResponse tests the parseResponse() method.
@@ -66,7 +68,7 @@ func TestParseResponse(t *testing.T) {
 			continue
 		}
 
-		havePeople, err := parseResponse(rows, Person{})
+		havePeople, err := parseResponse(rows, Person{}, MappingWarn)
 		if err != nil {
 			t.Errorf("TestParseResponse()[%d] - failed to parse response: %v.", i, err)
 			continue
@@ -84,3 +86,113 @@ func TestParseResponse(t *testing.T) {
 		}
 	}
 }
+
+// TestConvertValue tests the convertValue() function.
+func TestConvertValue(t *testing.T) {
+	type Status string
+
+	noon := time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		src     interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{nil, int32(0), false},
+		{nil, "", false},
+		{int64(42), int32(42), false},
+		{int64(1 << 40), int32(0), true},
+		{int64(-1), uint16(0), true},
+		{int64(7), float64(7), false},
+		{int64(1), true, false},
+		{float64(1.5), float32(1.5), false},
+		{float64(1e300), float32(0), true},
+		{true, true, false},
+		{[]byte("Adam"), "Adam", false},
+		{[]byte("running"), Status("running"), false},
+		{[]byte("12"), int64(12), false},
+		{[]byte("12.5"), float64(12.5), false},
+		{[]byte("1"), true, false},
+		{[]byte("twelve"), int64(0), true},
+		{[]byte{1, 2}, []byte{1, 2}, false},
+		{"2020-06-01 12:00:00", noon, false},
+		{noon.In(time.FixedZone("EST", -5*60*60)), noon, false},
+		{"Adam", int32(0), true},
+		{noon, "", true},
+	}
+	for i, test := range tests {
+		have, err := convertValue(test.src, reflect.TypeOf(test.want))
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestConvertValue()[%d] = %v, want error = %t.", i, err, test.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(have.Interface(), test.want) {
+			t.Errorf("TestConvertValue()[%d] = %v, want %v.", i, have.Interface(), test.want)
+		}
+	}
+}
+
+// TestFieldScanner tests the (*fieldScanner).Scan() method.
+func TestFieldScanner(t *testing.T) {
+	type Person struct {
+		Age   int32         `sql:"age"`
+		Shift time.Duration `sql:"shift"`
+	}
+
+	cols, _ := getColumns(reflect.TypeOf(Person{}))
+	vessel := reflect.New(reflect.TypeOf(Person{})).Elem()
+	if err := newFieldScanner(vessel, cols[0]).Scan(int64(30)); err != nil {
+		t.Errorf("TestFieldScanner() - failed to scan age: %v.", err)
+	}
+	if err := newFieldScanner(vessel, cols[1]).Scan([]byte("01:00:00")); err != nil {
+		t.Errorf("TestFieldScanner() - failed to scan shift: %v.", err)
+	}
+	want := Person{30, time.Hour}
+	if have := vessel.Interface().(Person); have != want {
+		t.Errorf("TestFieldScanner() = %v, want %v.", have, want)
+	}
+
+	// Verify that conversion errors identify the column and field.
+	err := newFieldScanner(vessel, cols[0]).Scan("thirty")
+	if err == nil || !strings.Contains(err.Error(), `column "age" into field Age`) {
+		t.Errorf("TestFieldScanner() = %v, want error naming column and field.", err)
+	}
+}
+
+// TestParseResponseErrors tests that parseResponse() reports values that cannot
+// be converted to the type of their field.
+func TestParseResponseErrors(t *testing.T) {
+	type Person struct {
+		Name string `sql:"name"`
+		Age  int32  `sql:"age"`
+	}
+
+	conn, err := Connect(GetTestCreds())
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v.", err)
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		query     string
+		wantCount int
+		wantErr   bool
+	}{
+		{`SELECT 'Adam' AS name, 10 AS age`, 1, false},
+		{`SELECT 'Adam' AS name, NULL::INT AS age`, 1, false},
+		{`SELECT 'Adam' AS name, 'ten' AS age`, 0, true},
+		{`SELECT 'Adam' AS name, 10000000000 AS age`, 0, true},
+		{`SELECT 1 / (3 - n) AS age FROM generate_series(1, 3) AS n`, 0, true},
+	}
+	for i, test := range tests {
+		rows, err := conn.query(test.query)
+		if err != nil {
+			t.Errorf("TestParseResponseErrors()[%d] - failed to execute query: %v.", i, err)
+			continue
+		}
+		people, err := parseResponse(rows, Person{}, MappingWarn)
+		if len(people) != test.wantCount || (err != nil) != test.wantErr {
+			t.Errorf("TestParseResponseErrors()[%d] = %v (%v), want %d people (error = %t).", i, people, err, test.wantCount, test.wantErr)
+		}
+	}
+
+}
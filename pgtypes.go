@@ -0,0 +1,282 @@
+package structql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Define the reflected network types that receive special treatment from the
+// encoders and decoders in types.go.
+var (
+	ipType    = reflect.TypeOf(net.IP{})
+	ipNetType = reflect.TypeOf(net.IPNet{})
+)
+
+// ipAddr scans a PostgreSQL INET into a net.IP.
+type ipAddr net.IP
+
+// Scan implements the sql.Scanner interface.
+func (ip *ipAddr) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*ip = nil
+		return nil
+	case []byte:
+		s = string(src)
+	case string:
+		s = src
+	default:
+		return fmt.Errorf("cannot scan type %T into a net.IP", src)
+	}
+
+	// An INET may carry a netmask; the address precedes it.
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		s = s[:i]
+	}
+	addr := net.ParseIP(s)
+	if addr == nil {
+		return fmt.Errorf("%q is not an IP address", s)
+	}
+	*ip = ipAddr(addr)
+	return nil
+}
+
+// ipNet scans a PostgreSQL CIDR into a net.IPNet.
+type ipNet net.IPNet
+
+// Scan implements the sql.Scanner interface.
+func (n *ipNet) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*n = ipNet{}
+		return nil
+	case []byte:
+		s = string(src)
+	case string:
+		s = src
+	default:
+		return fmt.Errorf("cannot scan type %T into a net.IPNet", src)
+	}
+
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a network: %w", s, err)
+	}
+	*n = ipNet(*network)
+	return nil
+}
+
+// encodeNetwork returns the textual representation of the given net.IP or
+// net.IPNet value.  A nil net.IP and a net.IPNet without an IP are encoded as
+// NULL.  The boolean result reports whether the value is of either type.
+func encodeNetwork(val reflect.Value) (interface{}, bool) {
+	switch val.Type() {
+	case ipType:
+		ip := val.Interface().(net.IP)
+		if ip == nil {
+			return nil, true
+		}
+		return ip.String(), true
+	case ipNetType:
+		network := val.Interface().(net.IPNet)
+		if network.IP == nil {
+			return nil, true
+		}
+		return network.String(), true
+	}
+	return nil, false
+}
+
+// Point is a point on a plane that maps to a POINT column.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// String returns the Point receiver in the PostgreSQL format (e.g., "(1,2)").
+func (p Point) String() string {
+	x := strconv.FormatFloat(p.X, 'g', -1, 64)
+	y := strconv.FormatFloat(p.Y, 'g', -1, 64)
+	return "(" + x + "," + y + ")"
+}
+
+// Value implements the driver.Valuer interface.
+func (p Point) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (p *Point) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*p = Point{}
+		return nil
+	case []byte:
+		s = string(src)
+	case string:
+		s = src
+	default:
+		return fmt.Errorf("cannot scan type %T into a Point", src)
+	}
+
+	coords := strings.Split(strings.Trim(s, "()"), ",")
+	if len(coords) != 2 {
+		return fmt.Errorf("%q is not a point", s)
+	}
+	x, err := strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a point: %w", s, err)
+	}
+	y, err := strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a point: %w", s, err)
+	}
+	*p = Point{x, y}
+	return nil
+}
+
+// TimeRange is a range of times that maps to a TSTZRANGE column.  A zero Lower
+// or Upper time denotes an unbounded end of the range.  By default, the lower
+// bound is inclusive and the upper bound is exclusive, as in PostgreSQL.
+type TimeRange struct {
+	Lower time.Time
+	Upper time.Time
+	// LowerExclusive reports whether the Lower time is excluded from the range.
+	LowerExclusive bool
+	// UpperInclusive reports whether the Upper time is included in the range.
+	UpperInclusive bool
+	// Empty reports whether the range contains no times at all.
+	Empty bool
+}
+
+// rangeTimeLayout is the layout of the times in a TimeRange literal.
+const rangeTimeLayout = "2006-01-02 15:04:05.999999999Z07:00"
+
+// String returns the TimeRange receiver as a PostgreSQL range literal (e.g.,
+// `["2020-01-01 00:00:00Z","2020-02-01 00:00:00Z")`).
+func (r TimeRange) String() string {
+	if r.Empty {
+		return "empty"
+	}
+
+	bound := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return `"` + t.Format(rangeTimeLayout) + `"`
+	}
+
+	lower, upper := "[", ")"
+	if r.LowerExclusive {
+		lower = "("
+	}
+	if r.UpperInclusive {
+		upper = "]"
+	}
+	return lower + bound(r.Lower) + "," + bound(r.Upper) + upper
+}
+
+// Value implements the driver.Valuer interface.
+func (r TimeRange) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (r *TimeRange) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*r = TimeRange{}
+		return nil
+	case []byte:
+		s = string(src)
+	case string:
+		s = src
+	default:
+		return fmt.Errorf("cannot scan type %T into a TimeRange", src)
+	}
+
+	if s == "empty" {
+		*r = TimeRange{Empty: true}
+		return nil
+	}
+	if len(s) < 3 || !strings.ContainsAny(s[:1], "[(") || !strings.ContainsAny(s[len(s)-1:], "])") {
+		return fmt.Errorf("%q is not a range", s)
+	}
+
+	bounds := strings.SplitN(s[1:len(s)-1], ",", 2)
+	if len(bounds) != 2 {
+		return fmt.Errorf("%q is not a range", s)
+	}
+	lower, err := parseRangeTime(bounds[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse lower bound of %q: %w", s, err)
+	}
+	upper, err := parseRangeTime(bounds[1])
+	if err != nil {
+		return fmt.Errorf("failed to parse upper bound of %q: %w", s, err)
+	}
+
+	*r = TimeRange{
+		Lower:          lower,
+		Upper:          upper,
+		LowerExclusive: s[0] == '(',
+		UpperInclusive: s[len(s)-1] == ']',
+	}
+	return nil
+}
+
+// parseRangeTime parses a bound of a PostgreSQL time range.  Missing and
+// infinite bounds yield the zero time.
+func parseRangeTime(s string) (time.Time, error) {
+	s = strings.Trim(s, `"`)
+	if s == "" || s == "infinity" || s == "-infinity" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999Z07", "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time", s)
+}
+
+// Overlaps returns a condition that is satisfied when the range in the given
+// column overlaps the provided TimeRange.  For example:
+//
+//  conn.SelectFromWhere(Booking{}, "bookings", Overlaps("period", r))
+func Overlaps(col string, r TimeRange) string {
+	return fmt.Sprintf("%s && %s::tstzrange", col, quoteLiteral(r.String()))
+}
+
+// Contains returns a condition that is satisfied when the range in the given
+// column contains the provided time.
+func Contains(col string, t time.Time) string {
+	return fmt.Sprintf("%s @> %s::timestamptz", col, quoteLiteral(t.Format(rangeTimeLayout)))
+}
+
+// ContainsRange returns a condition that is satisfied when the range in the
+// given column contains the entirety of the provided TimeRange.
+func ContainsRange(col string, r TimeRange) string {
+	return fmt.Sprintf("%s @> %s::tstzrange", col, quoteLiteral(r.String()))
+}
+
+// ContainedBy returns a condition that is satisfied when the range in the given
+// column lies entirely within the provided TimeRange.
+func ContainedBy(col string, r TimeRange) string {
+	return fmt.Sprintf("%s <@ %s::tstzrange", col, quoteLiteral(r.String()))
+}
+
+// WithinNetwork returns a condition that is satisfied when the INET or CIDR in
+// the given column lies within the provided network.
+func WithinNetwork(col string, network net.IPNet) string {
+	return fmt.Sprintf("%s <<= %s::cidr", col, quoteLiteral(network.String()))
+}
@@ -0,0 +1,137 @@
+// Package structql implements the Database structure.
+// This file contains tests for pgtypes.go.
+package structql
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestPointScan tests the (*Point).Scan() method.
+func TestPointScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    Point
+		wantErr bool
+	}{
+		{[]byte("(1,2)"), Point{1, 2}, false},
+		{"(-1.5,2e-3)", Point{-1.5, 0.002}, false},
+		{"(1)", Point{}, true},
+		{"(a,b)", Point{}, true},
+		{1.0, Point{}, true},
+	}
+	for i, test := range tests {
+		var have Point
+		err := have.Scan(test.src)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestPointScan()[%d] = %v, want error %t.", i, err, test.wantErr)
+		}
+		if have != test.want {
+			t.Errorf("TestPointScan()[%d] = %v, want Point %v.", i, have, test.want)
+		}
+	}
+}
+
+// TestTimeRangeScan tests the (*TimeRange).Scan() and TimeRange.String() methods.
+func TestTimeRangeScan(t *testing.T) {
+	jan := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2020, time.February, 1, 12, 30, 0, 500000000, time.UTC)
+
+	tests := []struct {
+		src     string
+		want    TimeRange
+		wantErr bool
+	}{
+		{`["2020-01-01 00:00:00+00","2020-02-01 12:30:00.5+00")`, TimeRange{Lower: jan, Upper: feb}, false},
+		{`("2019-12-31 19:00:00-05",]`, TimeRange{Lower: jan, LowerExclusive: true, UpperInclusive: true}, false},
+		{`[,"2020-02-01 18:00:00.5+05:30")`, TimeRange{Upper: feb}, false},
+		{`empty`, TimeRange{Empty: true}, false},
+		{`[2020-01-01)`, TimeRange{}, true},
+		{`[yesterday,)`, TimeRange{}, true},
+	}
+	for i, test := range tests {
+		var have TimeRange
+		err := have.Scan([]byte(test.src))
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestTimeRangeScan()[%d] = %v, want error %t.", i, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("TestTimeRangeScan()[%d] = %v, want TimeRange %v.", i, have, test.want)
+		}
+
+		// Verify that the TimeRange survives a round trip through its literal.
+		var trip TimeRange
+		if err := trip.Scan(have.String()); err != nil || !reflect.DeepEqual(trip, have) {
+			t.Errorf("TestTimeRangeScan()[%d] = %v (%v), want round trip of %v.", i, trip, err, have)
+		}
+	}
+}
+
+// TestEncodeNetwork tests the encodeNetwork() function and the scanners of the
+// net.IP and net.IPNet types.
+func TestEncodeNetwork(t *testing.T) {
+	_, network, _ := net.ParseCIDR("10.1.0.0/16")
+
+	tests := []struct {
+		value interface{}
+		want  interface{}
+		dest  interface{}
+	}{
+		{net.ParseIP("192.168.0.1"), "192.168.0.1", new(ipAddr)},
+		{net.ParseIP("2001:db8::1"), "2001:db8::1", new(ipAddr)},
+		{*network, "10.1.0.0/16", new(ipNet)},
+	}
+	for i, test := range tests {
+		have, ok := encodeNetwork(reflect.ValueOf(test.value))
+		if !ok || have != test.want {
+			t.Errorf("TestEncodeNetwork()[%d] = %v, want %v.", i, have, test.want)
+			continue
+		}
+
+		// Decode the encoded value and compare it to the original value.
+		dest := test.dest.(interface{ Scan(interface{}) error })
+		if err := dest.Scan([]byte(have.(string))); err != nil {
+			t.Errorf("TestEncodeNetwork()[%d] - failed to scan %q: %v.", i, have, err)
+			continue
+		}
+		decoded := decodeValue(reflect.ValueOf(dest).Elem(), reflect.TypeOf(test.value)).Interface()
+		if !reflect.DeepEqual(decoded, test.value) {
+			t.Errorf("TestEncodeNetwork()[%d] = %v, want decoded value %v.", i, decoded, test.value)
+		}
+	}
+
+	// Verify that zero values are encoded as NULL.
+	for i, value := range []interface{}{net.IP(nil), net.IPNet{}} {
+		if have, ok := encodeNetwork(reflect.ValueOf(value)); !ok || have != nil {
+			t.Errorf("TestEncodeNetwork()[zero %d] = (%v, %t), want (<nil>, true).", i, have, ok)
+		}
+	}
+}
+
+// TestRangeConditions tests the range and network condition helpers.
+func TestRangeConditions(t *testing.T) {
+	jan := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+
+	tests := []struct {
+		have string
+		want string
+	}{
+		{Overlaps("period", TimeRange{Lower: jan}), `period && '["2020-01-01 00:00:00Z",)'::tstzrange`},
+		{Contains("period", jan), `period @> '2020-01-01 00:00:00Z'::timestamptz`},
+		{ContainsRange("period", TimeRange{Empty: true}), `period @> 'empty'::tstzrange`},
+		{ContainedBy("period", TimeRange{Upper: jan, UpperInclusive: true}), `period <@ '[,"2020-01-01 00:00:00Z"]'::tstzrange`},
+		{WithinNetwork("addr", *network), `addr <<= '10.0.0.0/8'::cidr`},
+	}
+	for i, test := range tests {
+		if test.have != test.want {
+			t.Errorf("TestRangeConditions()[%d] = %q, want %q.", i, test.have, test.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package structql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Nulls controls where NULL values are placed when rows are sorted.
+type Nulls int
+
+const (
+	// NullsDefault places NULL values where the database places them by default.
+	NullsDefault Nulls = iota
+	// NullsFirst places NULL values before all other values.
+	NullsFirst
+	// NullsLast places NULL values after all other values.
+	NullsLast
+)
+
+// Order sorts the rows of a query by a column.
+type Order struct {
+	// Column is the name of the SQL column (or an SQL expression).
+	Column string
+	// Desc reports whether the rows are sorted in descending order.
+	Desc bool
+	// Nulls controls where NULL values are placed.
+	Nulls Nulls
+}
+
+// Asc returns an Order that sorts rows by the given column in ascending order.
+func Asc(column string) Order {
+	return Order{Column: column}
+}
+
+// Desc returns an Order that sorts rows by the given column in descending order.
+func Desc(column string) Order {
+	return Order{Column: column, Desc: true}
+}
+
+// NullsFirst returns a copy of the Order receiver that places NULL values first.
+func (o Order) NullsFirst() Order {
+	o.Nulls = NullsFirst
+	return o
+}
+
+// NullsLast returns a copy of the Order receiver that places NULL values last.
+func (o Order) NullsLast() Order {
+	o.Nulls = NullsLast
+	return o
+}
+
+// String returns the ORDER BY term of the Order receiver.
+func (o Order) String() string {
+	term := o.Column + " ASC"
+	if o.Desc {
+		term = o.Column + " DESC"
+	}
+	switch o.Nulls {
+	case NullsFirst:
+		term += " NULLS FIRST"
+	case NullsLast:
+		term += " NULLS LAST"
+	}
+	return term
+}
+
+// QueryOption modifies the rows returned by SelectFrom() and SelectFromWhere().
+type QueryOption func(*queryOptions)
+
+// queryOptions holds the clauses that are set by QueryOptions.
+type queryOptions struct {
+	// orders are the terms of the ORDER BY clause.
+	orders []Order
+	// limit is the maximum number of rows, provided that hasLimit is set.
+	limit    int
+	hasLimit bool
+	// offset is the number of rows to skip.
+	offset int
+	// distinct reports whether duplicate rows are removed.
+	distinct bool
+	// distinctOn are the expressions of a DISTINCT ON clause.
+	distinctOn []string
+}
+
+// OrderBy sorts the rows by the given orders.  Repeated OrderBy options append
+// to the sort key.
+func OrderBy(orders ...Order) QueryOption {
+	return func(opts *queryOptions) {
+		opts.orders = append(opts.orders, orders...)
+	}
+}
+
+// Limit returns at most n rows.
+func Limit(n int) QueryOption {
+	return func(opts *queryOptions) {
+		opts.limit, opts.hasLimit = n, true
+	}
+}
+
+// Offset skips the first n rows.  Note that keyset pagination (see Page()) is
+// considerably faster than large offsets.
+func Offset(n int) QueryOption {
+	return func(opts *queryOptions) {
+		opts.offset = n
+	}
+}
+
+// Distinct removes duplicate rows.
+func Distinct() QueryOption {
+	return func(opts *queryOptions) {
+		opts.distinct = true
+	}
+}
+
+// DistinctOn keeps only the first row of each set of rows that share the values
+// of the given columns.  The ORDER BY clause must begin with the same columns.
+// DISTINCT ON is only supported by PostgreSQL.
+func DistinctOn(columns ...string) QueryOption {
+	return func(opts *queryOptions) {
+		opts.distinctOn = append(opts.distinctOn, columns...)
+	}
+}
+
+// newQueryOptions applies the given QueryOptions.
+func newQueryOptions(options []QueryOption) queryOptions {
+	opts := queryOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	return opts
+}
+
+// splitQueryOptions separates the QueryOptions from the printf() arguments of a
+// conditional.
+func splitQueryOptions(args []interface{}) ([]interface{}, queryOptions) {
+	vals := []interface{}{}
+	options := []QueryOption{}
+	for _, arg := range args {
+		if option, ok := arg.(QueryOption); ok {
+			options = append(options, option)
+		} else {
+			vals = append(vals, arg)
+		}
+	}
+	return vals, newQueryOptions(options)
+}
+
+// selectStmt constructs a SELECT statement for the given list of columns, table,
+// and conditional with the clauses of the provided queryOptions.  Setting the
+// conditional to "" indicates that no conditional is desired.
+func (conn *Connection) selectStmt(selectList string, table string, cond string, opts queryOptions) (string, error) {
+	if opts.hasLimit && opts.limit < 0 {
+		return "", fmt.Errorf("limit %d is negative", opts.limit)
+	}
+	if opts.offset < 0 {
+		return "", fmt.Errorf("offset %d is negative", opts.offset)
+	}
+
+	stmt := "SELECT "
+	switch {
+	case len(opts.distinctOn) > 0:
+		if conn.driver == MySQL {
+			return "", fmt.Errorf("DISTINCT ON is not supported by the %s driver", conn.driver)
+		}
+		stmt += fmt.Sprintf("DISTINCT ON (%s) ", strings.Join(opts.distinctOn, ", "))
+	case opts.distinct:
+		stmt += "DISTINCT "
+	}
+	stmt += fmt.Sprintf("%s FROM %s", selectList, table)
+	if cond != "" {
+		stmt += " WHERE " + cond
+	}
+
+	if len(opts.orders) > 0 {
+		terms := make([]string, len(opts.orders))
+		for i, order := range opts.orders {
+			terms[i] = conn.orderTerm(order)
+		}
+		stmt += " ORDER BY " + strings.Join(terms, ", ")
+	}
+	switch {
+	case opts.hasLimit:
+		stmt += fmt.Sprintf(" LIMIT %d", opts.limit)
+	case opts.offset > 0 && conn.driver == MySQL:
+		// MySQL does not accept an OFFSET clause without a LIMIT clause.
+		stmt += " LIMIT 18446744073709551615"
+	}
+	if opts.offset > 0 {
+		stmt += fmt.Sprintf(" OFFSET %d", opts.offset)
+	}
+	return stmt + ";", nil
+}
+
+// orderTerm returns the ORDER BY term of the given Order.  MySQL does not support
+// NULLS FIRST and NULLS LAST, so the placement of NULL values is emulated by
+// sorting on whether the column is NULL.
+func (conn *Connection) orderTerm(order Order) string {
+	if conn.driver != MySQL || order.Nulls == NullsDefault {
+		return order.String()
+	}
+	nulls := "DESC"
+	if order.Nulls == NullsLast {
+		nulls = "ASC"
+	}
+	order.Nulls = NullsDefault
+	return fmt.Sprintf("%s IS NULL %s, %s", order.Column, nulls, order)
+}
@@ -0,0 +1,163 @@
+// Package structql implements the Database structure.
+// This file contains tests for query.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestOrderString tests the String() method of the Order type.
+func TestOrderString(t *testing.T) {
+	tests := []struct {
+		order Order
+		want  string
+	}{
+		{Asc("name"), "name ASC"},
+		{Desc("age"), "age DESC"},
+		{Asc("age").NullsFirst(), "age ASC NULLS FIRST"},
+		{Desc("lower(name)").NullsLast(), "lower(name) DESC NULLS LAST"},
+	}
+	for i, test := range tests {
+		if have := test.order.String(); have != test.want {
+			t.Errorf("TestOrderString()[%d] = %q, want %q.", i, have, test.want)
+		}
+	}
+}
+
+// TestSelectStmt tests the selectStmt() method of the Connection type.
+func TestSelectStmt(t *testing.T) {
+	tests := []struct {
+		driver  Driver
+		cond    string
+		options []QueryOption
+		want    string
+		wantErr bool
+	}{
+		{
+			Postgres,
+			"",
+			nil,
+			"SELECT id, name FROM people;",
+			false,
+		}, {
+			Postgres,
+			"age > 30",
+			[]QueryOption{OrderBy(Desc("age").NullsLast()), OrderBy(Asc("name")), Limit(10), Offset(20)},
+			"SELECT id, name FROM people WHERE age > 30 ORDER BY age DESC NULLS LAST, name ASC LIMIT 10 OFFSET 20;",
+			false,
+		}, {
+			Postgres,
+			"",
+			[]QueryOption{Distinct(), Limit(0)},
+			"SELECT DISTINCT id, name FROM people LIMIT 0;",
+			false,
+		}, {
+			Postgres,
+			"",
+			[]QueryOption{DistinctOn("name"), OrderBy(Asc("name"), Desc("id"))},
+			"SELECT DISTINCT ON (name) id, name FROM people ORDER BY name ASC, id DESC;",
+			false,
+		}, {
+			MySQL,
+			"",
+			[]QueryOption{OrderBy(Asc("age").NullsLast(), Desc("name").NullsFirst()), Offset(5)},
+			"SELECT id, name FROM people ORDER BY age IS NULL ASC, age ASC, name IS NULL DESC, name DESC LIMIT 18446744073709551615 OFFSET 5;",
+			false,
+		}, {
+			MySQL,
+			"",
+			[]QueryOption{DistinctOn("name")},
+			"",
+			true,
+		}, {
+			Postgres,
+			"",
+			[]QueryOption{Limit(-1)},
+			"",
+			true,
+		}, {
+			Postgres,
+			"",
+			[]QueryOption{Offset(-1)},
+			"",
+			true,
+		},
+	}
+	for i, test := range tests {
+		conn := &Connection{driver: test.driver}
+		have, err := conn.selectStmt("id, name", "people", test.cond, newQueryOptions(test.options))
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestSelectStmt()[%d] = %v, want error %t.", i, err, test.wantErr)
+			continue
+		}
+		if have != test.want {
+			t.Errorf("TestSelectStmt()[%d] = %q, want %q.", i, have, test.want)
+		}
+	}
+}
+
+// TestSplitQueryOptions tests the splitQueryOptions() function.
+func TestSplitQueryOptions(t *testing.T) {
+	args, opts := splitQueryOptions([]interface{}{30, Limit(5), "Ann", OrderBy(Asc("name"))})
+	if want := []interface{}{30, "Ann"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("TestSplitQueryOptions() = %v, want arguments %v.", args, want)
+	}
+	if want := (queryOptions{orders: []Order{Asc("name")}, limit: 5, hasLimit: true}); !reflect.DeepEqual(opts, want) {
+		t.Errorf("TestSplitQueryOptions() = %+v, want options %+v.", opts, want)
+	}
+}
+
+// TestSelectOptions tests the QueryOptions of the SelectFrom() and
+// SelectFromWhere() methods of the Connection type.
+func TestSelectOptions(t *testing.T) {
+	type Person struct {
+		ID   int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Name string `sql:"name"`
+		Age  int32  `sql:"age"`
+	}
+
+	conn := createTableUnsafe("People", Person{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	people := []Person{{1, "Ann", 20}, {2, "Bob", 30}, {3, "Cat", 30}, {4, "Dan", 40}}
+	if _, err := conn.InsertObjects("People", people); err != nil {
+		t.Fatalf("Failed to insert People: %v.", err)
+	}
+
+	tests := []struct {
+		cond string
+		args []interface{}
+		want []interface{}
+	}{
+		{
+			"",
+			[]interface{}{OrderBy(Desc("age"), Asc("id")), Limit(2), Offset(1)},
+			[]interface{}{people[1], people[2]},
+		}, {
+			"age >= %d",
+			[]interface{}{30, OrderBy(Desc("name"))},
+			[]interface{}{people[3], people[2], people[1]},
+		}, {
+			"",
+			[]interface{}{DistinctOn("age"), OrderBy(Asc("age"), Desc("id"))},
+			[]interface{}{people[0], people[2], people[3]},
+		},
+	}
+	for i, test := range tests {
+		have, err := conn.SelectFromWhere(Person{}, "People", test.cond, test.args...)
+		if err != nil {
+			t.Errorf("TestSelectOptions()[%d] = %v, want no error.", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("TestSelectOptions()[%d] = %v, want %v.", i, have, test.want)
+		}
+	}
+
+	have, err := conn.SelectFrom(Person{}, "People", OrderBy(Asc("id")), Limit(1))
+	if want := []interface{}{people[0]}; err != nil || !reflect.DeepEqual(have, want) {
+		t.Errorf("TestSelectOptions() = %v (%v), want %v.", have, err, want)
+	}
+}
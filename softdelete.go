@@ -0,0 +1,133 @@
+package structql
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// softDeletes records the soft-delete column of each table that is known to
+// have one.  The record is shared by a Connection and the views returned by
+// its WithDeleted() method.
+type softDeletes struct {
+	sync.RWMutex
+	columns map[string]string
+}
+
+// softDeleteColumn returns the column of the given columns that records when
+// an object was soft-deleted.  A column is used for this purpose when its "sql"
+// tag carries the "softdelete" option:
+//
+//  type Invoice struct {
+//    ID      int32     `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+//    Deleted time.Time `sql:"deleted_at,softdelete"`
+//  }
+//
+// The boolean result reports whether such a column exists.
+func softDeleteColumn(cols []column) (column, bool) {
+	for _, col := range cols {
+		if col.opts.has("softdelete") {
+			return col, true
+		}
+	}
+	return column{}, false
+}
+
+// deletedColumn returns the name of the soft-delete column of the given table.
+// If the given columns of an object declare a soft-delete column, it is used
+// and remembered for the table; otherwise, the remembered column is returned.
+// The boolean result reports whether the table has a soft-delete column.
+func (conn *Connection) deletedColumn(table string, cols []column) (string, bool) {
+	if col, ok := softDeleteColumn(cols); ok {
+		conn.RegisterSoftDelete(table, col.name)
+		return col.name, true
+	}
+	if conn.softDeletes == nil {
+		return "", false
+	}
+
+	conn.softDeletes.RLock()
+	defer conn.softDeletes.RUnlock()
+	name, ok := conn.softDeletes.columns[table]
+	return name, ok
+}
+
+// excludeDeleted extends the given conditional so that it is not satisfied by
+// the soft-deleted rows of the specified table.  An empty conditional is
+// satisfied by every row.  The conditional is returned unchanged if the table
+// does not have a soft-delete column or if the Connection receiver was obtained
+// from WithDeleted().
+func (conn *Connection) excludeDeleted(table string, cols []column, cond string) string {
+	name, ok := conn.deletedColumn(table, cols)
+	if !ok || conn.withDeleted {
+		return cond
+	}
+	if cond == "" {
+		return fmt.Sprintf("%s IS NULL", name)
+	}
+	return fmt.Sprintf("%s IS NULL AND (%s)", name, cond)
+}
+
+// WithDeleted returns a view of the Connection receiver whose queries include
+// soft-deleted rows.  For example:
+//
+//  invoices, err := conn.WithDeleted().SelectFrom(Invoice{}, "invoices")
+//
+// The view shares the underlying database connection with the receiver, so
+// closing either one closes both.
+func (conn *Connection) WithDeleted() *Connection {
+	view := *conn
+	view.withDeleted = true
+	return &view
+}
+
+// HardDelete permanently deletes the given object from the specified table,
+// even if the object declares a soft-delete column.  The row to delete is
+// identified by the primary key columns of the object.
+func (conn *Connection) HardDelete(table string, object interface{}) error {
+	return conn.deleteObject(table, object, true)
+}
+
+// RegisterSoftDelete records that the given column of the specified table holds
+// the time at which a row was soft-deleted.  Tables created with
+// CreateTableFromObject() and tables that have been queried with a structure
+// declaring a soft-delete column are recorded automatically, but only on the
+// Connection that did so.  This method is needed to exclude soft-deleted rows
+// from CountRows() and CountRowsWhere() before then, e.g., on a new Connection
+// to an existing table; alternatively, use CountObjects() and
+// CountObjectsWhere(), which derive the column from the tags of a structure.
+func (conn *Connection) RegisterSoftDelete(table string, column string) {
+	if conn.softDeletes == nil {
+		return
+	}
+
+	// Every query with a soft-deletable structure registers its column, so the
+	// write lock is only taken when the record actually changes.
+	conn.softDeletes.RLock()
+	known, ok := conn.softDeletes.columns[table]
+	conn.softDeletes.RUnlock()
+	if ok && known == column {
+		return
+	}
+
+	conn.softDeletes.Lock()
+	defer conn.softDeletes.Unlock()
+	conn.softDeletes.columns[table] = column
+}
+
+// nullTime scans a nullable timestamp into a time.Time.  A NULL timestamp
+// yields the zero time.
+type nullTime time.Time
+
+// Scan implements the sql.Scanner interface.
+func (t *nullTime) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*t = nullTime{}
+		return nil
+	case time.Time:
+		*t = nullTime(src)
+		return nil
+	}
+	return fmt.Errorf("cannot scan type %T into a time.Time", src)
+}
@@ -0,0 +1,198 @@
+// Package structql implements the Database structure.
+// This file contains tests for softdelete.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestExcludeDeleted tests the (*Connection).excludeDeleted() method.
+func TestExcludeDeleted(t *testing.T) {
+	type Invoice struct {
+		ID      int32     `sql:"id"`
+		Deleted time.Time `sql:"deleted_at,softdelete"`
+	}
+	type Person struct {
+		ID int32 `sql:"id"`
+	}
+
+	invoiceCols, _ := getColumns(reflect.TypeOf(Invoice{}))
+	personCols, _ := getColumns(reflect.TypeOf(Person{}))
+	conn := &Connection{softDeletes: &softDeletes{columns: map[string]string{}}}
+	conn.RegisterSoftDelete("receipts", "removed_at")
+
+	tests := []struct {
+		conn  *Connection
+		table string
+		cols  []column
+		cond  string
+		want  string
+	}{
+		{conn, "invoices", invoiceCols, "", "deleted_at IS NULL"},
+		{conn, "invoices", invoiceCols, "id = 1 OR id = 2", "deleted_at IS NULL AND (id = 1 OR id = 2)"},
+		{conn, "invoices", nil, "id = 1", "deleted_at IS NULL AND (id = 1)"},
+		{conn, "receipts", nil, "", "removed_at IS NULL"},
+		{conn, "people", personCols, "id = 1", "id = 1"},
+		{conn.WithDeleted(), "invoices", invoiceCols, "id = 1", "id = 1"},
+		{&Connection{}, "invoices", invoiceCols, "", "deleted_at IS NULL"},
+		{&Connection{}, "receipts", nil, "", ""},
+	}
+	for i, test := range tests {
+		have := test.conn.excludeDeleted(test.table, test.cols, test.cond)
+		if have != test.want {
+			t.Errorf("TestExcludeDeleted()[%d] = %q, want %q.", i, have, test.want)
+		}
+	}
+}
+
+// TestDeletedColumnReadLock tests that (*Connection).deletedColumn() does not
+// take the write lock of a soft-delete column that is already registered.
+func TestDeletedColumnReadLock(t *testing.T) {
+	type Invoice struct {
+		ID      int32     `sql:"id"`
+		Deleted time.Time `sql:"deleted_at,softdelete"`
+	}
+
+	cols, _ := getColumns(reflect.TypeOf(Invoice{}))
+	conn := &Connection{softDeletes: &softDeletes{columns: map[string]string{}}}
+	conn.RegisterSoftDelete("invoices", "deleted_at")
+
+	// A writer would wait for the read lock held here.
+	conn.softDeletes.RLock()
+	defer conn.softDeletes.RUnlock()
+	done := make(chan string, 1)
+	go func() {
+		name, _ := conn.deletedColumn("invoices", cols)
+		done <- name
+	}()
+	select {
+	case name := <-done:
+		if name != "deleted_at" {
+			t.Errorf("TestDeletedColumnReadLock() = %q, want %q.", name, "deleted_at")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("TestDeletedColumnReadLock() - deletedColumn() blocked on the write lock.")
+	}
+}
+
+// TestNullTime tests the (*nullTime).Scan() method.
+func TestNullTime(t *testing.T) {
+	now := time.Date(2020, time.March, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		src     interface{}
+		want    time.Time
+		wantErr bool
+	}{
+		{nil, time.Time{}, false},
+		{now, now, false},
+		{"2020-03-04", time.Time{}, true},
+	}
+	for i, test := range tests {
+		var have nullTime
+		err := have.Scan(test.src)
+		if !time.Time(have).Equal(test.want) || (err != nil) != test.wantErr {
+			t.Errorf("TestNullTime()[%d] = (%v, %v), want (%v, error = %t).", i, time.Time(have), err, test.want, test.wantErr)
+		}
+	}
+}
+
+// TestSoftDelete tests that soft-deleted objects are retained but hidden.
+func TestSoftDelete(t *testing.T) {
+	type Invoice struct {
+		ID      int32     `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Total   int64     `sql:"total"`
+		Deleted time.Time `sql:"deleted_at,softdelete"`
+	}
+
+	conn := createTableUnsafe("Invoices", Invoice{})
+	defer conn.Close()
+	defer conn.DropTable("Invoices")
+
+	invoices := []Invoice{{Total: 10}, {Total: 20}, {Total: 30}}
+	for i := range invoices {
+		if _, err := conn.InsertObject("Invoices", &invoices[i]); err != nil {
+			t.Fatalf("Failed to insert Invoice: %v.", err)
+		}
+	}
+
+	// Soft-delete the first invoice and permanently delete the second.
+	if err := conn.DeleteObject("Invoices", invoices[0]); err != nil {
+		t.Fatalf("TestSoftDelete() - failed to delete Invoice: %v.", err)
+	}
+	if err := conn.HardDelete("Invoices", invoices[1]); err != nil {
+		t.Fatalf("TestSoftDelete() - failed to hard-delete Invoice: %v.", err)
+	}
+
+	rows, err := conn.SelectFrom(Invoice{}, "Invoices")
+	if err != nil || len(rows) != 1 || !reflect.DeepEqual(rows[0], invoices[2]) {
+		t.Errorf("TestSoftDelete() = %v (%v), want Invoice %v.", rows, err, invoices[2])
+	}
+	if n, err := conn.CountRows("Invoices"); err != nil || n != 1 {
+		t.Errorf("TestSoftDelete() = %d (%v), want 1 row.", n, err)
+	}
+	if n, err := conn.CountRowsWhere("Invoices", "total < 25"); err != nil || n != 0 {
+		t.Errorf("TestSoftDelete() = %d (%v), want 0 rows.", n, err)
+	}
+
+	// Verify that the soft-deleted invoice can still be retrieved.
+	rows, err = conn.WithDeleted().SelectFromWhere(Invoice{}, "Invoices", "total < 25")
+	if err != nil || len(rows) != 1 || rows[0].(Invoice).Deleted.IsZero() {
+		t.Errorf("TestSoftDelete() = %v (%v), want 1 deleted Invoice.", rows, err)
+	}
+	if n, err := conn.WithDeleted().CountRows("Invoices"); err != nil || n != 2 {
+		t.Errorf("TestSoftDelete() = %d (%v), want 2 rows.", n, err)
+	}
+}
+
+// TestSoftDeleteNewConnection tests that a new Connection to an existing table
+// excludes soft-deleted rows whenever it is given a structure.
+func TestSoftDeleteNewConnection(t *testing.T) {
+	type Invoice struct {
+		ID      int32     `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Total   int64     `sql:"total"`
+		Deleted time.Time `sql:"deleted_at,softdelete"`
+	}
+
+	conn := createTableUnsafe("Invoices", Invoice{})
+	defer conn.Close()
+	defer conn.DropTable("Invoices")
+
+	invoices := []Invoice{{Total: 10}, {Total: 20}}
+	for i := range invoices {
+		if _, err := conn.InsertObject("Invoices", &invoices[i]); err != nil {
+			t.Fatalf("Failed to insert Invoice: %v.", err)
+		}
+	}
+	if err := conn.DeleteObject("Invoices", invoices[0]); err != nil {
+		t.Fatalf("TestSoftDeleteNewConnection() - failed to delete Invoice: %v.", err)
+	}
+
+	// The new Connection has not learned the soft-delete column of the table.
+	fresh, err := Connect(GetTestCreds())
+	if err != nil {
+		t.Fatalf("TestSoftDeleteNewConnection() - failed to connect: %v.", err)
+	}
+	defer fresh.Close()
+
+	if n, err := fresh.CountObjects(Invoice{}, "Invoices"); err != nil || n != 1 {
+		t.Errorf("TestSoftDeleteNewConnection() = %d (%v), want 1 counted object.", n, err)
+	}
+	if n, err := fresh.CountObjectsWhere(Invoice{}, "Invoices", "total < 25"); err != nil || n != 1 {
+		t.Errorf("TestSoftDeleteNewConnection() = %d (%v), want 1 counted object.", n, err)
+	}
+	if n, err := fresh.CountRows("Invoices"); err != nil || n != 2 {
+		t.Errorf("TestSoftDeleteNewConnection() = %d (%v), want 2 rows before registration.", n, err)
+	}
+
+	rows, err := fresh.SelectFrom(Invoice{}, "Invoices")
+	if err != nil || len(rows) != 1 || !reflect.DeepEqual(rows[0], invoices[1]) {
+		t.Errorf("TestSoftDeleteNewConnection() = %v (%v), want Invoice %v.", rows, err, invoices[1])
+	}
+
+	// Querying with the structure teaches the Connection the soft-delete column.
+	if n, err := fresh.CountRows("Invoices"); err != nil || n != 1 {
+		t.Errorf("TestSoftDeleteNewConnection() = %d (%v), want 1 row after a query.", n, err)
+	}
+}
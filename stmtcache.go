@@ -0,0 +1,243 @@
+package structql
+
+import (
+	"container/list"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// stmtCache is a least-recently-used cache of prepared statements keyed by their
+// SQL.  It is enabled by the StatementCacheSize of a ConnectionConfig and is
+// safe for concurrent use.
+type stmtCache struct {
+	sync.Mutex
+	// size is the maximum number of cached statements.
+	size int
+	// order holds the cached statements from most to least recently used.
+	order *list.List
+	// stmts maps the SQL of each cached statement to its element in order.
+	stmts map[string]*list.Element
+}
+
+// cachedStmt is a prepared statement held by a stmtCache.  A statement that is
+// removed from the cache while it is in use is closed once it is released.
+type cachedStmt struct {
+	query string
+	stmt  *sql.Stmt
+	// refs is the number of callers that are using the statement.
+	refs int
+	// removed reports whether the statement has been removed from the cache.
+	removed bool
+}
+
+// newStmtCache returns an empty stmtCache that holds up to size statements.
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{size: size, order: list.New(), stmts: map[string]*list.Element{}}
+}
+
+// acquire returns the cached statement with the given SQL, preparing it on the
+// provided database if it is not cached.  The statement must be passed to
+// release() once it is no longer used.  The least recently used statement is
+// removed if the cache overflows.
+func (c *stmtCache) acquire(db *sql.DB, query string) (*cachedStmt, error) {
+	c.Lock()
+	if elem, ok := c.stmts[query]; ok {
+		c.order.MoveToFront(elem)
+		cached := elem.Value.(*cachedStmt)
+		cached.refs++
+		c.Unlock()
+		return cached, nil
+	}
+	c.Unlock()
+
+	// Prepare the statement without holding the lock since it requires a round trip.
+	stmt, err := db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.stmts[query]; ok {
+		// Another goroutine prepared the same statement in the meantime.
+		stmt.Close()
+		c.order.MoveToFront(elem)
+		cached := elem.Value.(*cachedStmt)
+		cached.refs++
+		return cached, nil
+	}
+	cached := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.stmts[query] = c.order.PushFront(cached)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return cached, nil
+}
+
+// release gives up a use of the given statement, closing it if it has been
+// removed from the cache and is no longer in use.
+func (c *stmtCache) release(cached *cachedStmt) {
+	c.Lock()
+	defer c.Unlock()
+	cached.refs--
+	if cached.removed && cached.refs == 0 {
+		cached.stmt.Close()
+	}
+}
+
+// evict removes the statement with the given SQL from the cache.
+func (c *stmtCache) evict(query string) {
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.stmts[query]; ok {
+		c.remove(elem)
+	}
+}
+
+// purge removes every statement from the cache.
+func (c *stmtCache) purge() {
+	c.Lock()
+	defer c.Unlock()
+	for c.order.Len() > 0 {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove removes the statement in the given element from the cache and closes
+// it unless it is in use.  The caller must hold the lock of the stmtCache
+// receiver.
+func (c *stmtCache) remove(elem *list.Element) {
+	cached := c.order.Remove(elem).(*cachedStmt)
+	delete(c.stmts, cached.query)
+	cached.removed = true
+	if cached.refs == 0 {
+		cached.stmt.Close()
+	}
+}
+
+// run invokes the given function with the cached statement for the provided
+// SQL.  If the statement fails because the schema changed since it was
+// prepared, it is prepared again and the function is retried once.  Rows that
+// are returned by the statement remain valid after the statement is closed.
+func (c *stmtCache) run(db *sql.DB, query string, fn func(*sql.Stmt) error) error {
+	for attempt := 0; ; attempt++ {
+		cached, err := c.acquire(db, query)
+		if err != nil {
+			return err
+		}
+		err = fn(cached.stmt)
+		c.release(cached)
+		if err == nil || !isStaleStmt(err) {
+			return err
+		}
+		c.evict(query)
+		if attempt > 0 {
+			return err
+		}
+	}
+}
+
+// isCacheable reports whether the given SQL statement is worth preparing, which
+// is the case for queries and data modifications.
+func isCacheable(query string) bool {
+	switch statementVerb(query) {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "WITH":
+		return true
+	}
+	return false
+}
+
+// isSchemaChange reports whether the given SQL statement may change the schema
+// of the database and thereby invalidate prepared statements.
+func isSchemaChange(query string) bool {
+	switch statementVerb(query) {
+	case "CREATE", "DROP", "ALTER", "TRUNCATE", "DO":
+		return true
+	}
+	return false
+}
+
+// statementVerb returns the first word of the given SQL statement in upper case.
+func statementVerb(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// isStaleStmt reports whether the given error indicates that a prepared
+// statement no longer matches the schema of the database or no longer exists.
+func isStaleStmt(err error) bool {
+	var pqErr *pq.Error
+	var mysqlErr *mysql.MySQLError
+	switch {
+	case errors.As(err, &pqErr):
+		switch pqErr.Code {
+		case "0A000": // feature_not_supported (e.g., "cached plan must not change result type")
+			return strings.Contains(pqErr.Message, "cached plan")
+		case "26000", "42P01", "42703": // invalid_sql_statement_name, undefined_table, undefined_column
+			return true
+		}
+	case errors.As(err, &mysqlErr):
+		// ER_NEED_REPREPARE and ER_UNKNOWN_STMT_HANDLER.
+		return mysqlErr.Number == 1615 || mysqlErr.Number == 1243
+	}
+	return false
+}
+
+// txStmt returns a statement with the given SQL that executes in the provided
+// transaction, reusing the cached prepared statement if the statement cache of
+// the Connection receiver is enabled.  A nil statement indicates that the SQL
+// should be executed directly.
+func (conn *Connection) txStmt(tx *sql.Tx, query string) (*sql.Stmt, error) {
+	if conn.stmts == nil || !isCacheable(query) {
+		return nil, nil
+	}
+	cached, err := conn.stmts.acquire(conn.db, query)
+	if err != nil {
+		return nil, err
+	}
+	// The transaction statement keeps the cached statement open while it is used.
+	defer conn.stmts.release(cached)
+	return tx.Stmt(cached.stmt), nil
+}
+
+// txExec executes the given SQL statement with the provided arguments in the
+// transaction.
+func (conn *Connection) txExec(tx *sql.Tx, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := conn.txStmt(tx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return tx.Exec(query, args...)
+	}
+	result, err := stmt.Exec(args...)
+	if isStaleStmt(err) {
+		conn.stmts.evict(query)
+	}
+	return result, err
+}
+
+// txQuery executes the given SQL query with the provided arguments in the
+// transaction.
+func (conn *Connection) txQuery(tx *sql.Tx, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := conn.txStmt(tx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return tx.Query(query, args...)
+	}
+	rows, err := stmt.Query(args...)
+	if isStaleStmt(err) {
+		conn.stmts.evict(query)
+	}
+	return rows, err
+}
@@ -0,0 +1,300 @@
+// Package structql implements the Database structure.
+// This file contains tests for stmtcache.go.
+package structql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// fakeDriver is a database driver that records the statements it prepares.
+type fakeDriver struct {
+	sync.Mutex
+	// prepared counts the number of times each statement was prepared.
+	prepared map[string]int
+	// closed counts the number of times each statement was closed.
+	closed map[string]int
+}
+
+// Open implements the driver.Driver interface.
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{d}, nil
+}
+
+// fakeConn is a connection of a fakeDriver.
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+// Prepare implements the driver.Conn interface.
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.driver.Lock()
+	defer c.driver.Unlock()
+	c.driver.prepared[query]++
+	return &fakeStmt{c.driver, query}, nil
+}
+
+// Close implements the driver.Conn interface.
+func (c *fakeConn) Close() error { return nil }
+
+// Begin implements the driver.Conn interface.
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+
+// Commit implements the driver.Tx interface.
+func (c *fakeConn) Commit() error { return nil }
+
+// Rollback implements the driver.Tx interface.
+func (c *fakeConn) Rollback() error { return nil }
+
+// fakeStmt is a statement prepared by a fakeConn.
+type fakeStmt struct {
+	driver *fakeDriver
+	query  string
+}
+
+// Close implements the driver.Stmt interface.
+func (s *fakeStmt) Close() error {
+	s.driver.Lock()
+	defer s.driver.Unlock()
+	s.driver.closed[s.query]++
+	return nil
+}
+
+// NumInput implements the driver.Stmt interface.
+func (s *fakeStmt) NumInput() int { return -1 }
+
+// Exec implements the driver.Stmt interface.
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+
+// Query implements the driver.Stmt interface.
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) { return fakeRows{}, nil }
+
+// fakeRows is an empty result set.
+type fakeRows struct{}
+
+// Columns implements the driver.Rows interface.
+func (fakeRows) Columns() []string { return []string{} }
+
+// Close implements the driver.Rows interface.
+func (fakeRows) Close() error { return nil }
+
+// Next implements the driver.Rows interface.
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+// fake is the fakeDriver that is registered with database/sql.
+var fake = &fakeDriver{prepared: map[string]int{}, closed: map[string]int{}}
+
+func init() {
+	sql.Register("structql-fake", fake)
+}
+
+// TestStmtCache tests the statement cache of a Connection.
+func TestStmtCache(t *testing.T) {
+	db, err := sql.Open("structql-fake", "")
+	if err != nil {
+		t.Fatalf("Failed to open fake database: %v.", err)
+	}
+	conn := &Connection{db: db, stmts: newStmtCache(2)}
+
+	// Execute statements A, B, A, C, A: B is evicted when C is prepared.
+	for _, query := range []string{"SELECT a", "SELECT b", "SELECT a", "UPDATE c SET x = 1", "SELECT a"} {
+		if _, err := conn.exec(query); err != nil {
+			t.Fatalf("TestStmtCache() - failed to execute %q: %v.", query, err)
+		}
+	}
+	rows, err := conn.query("SELECT b")
+	if err != nil {
+		t.Fatalf("TestStmtCache() - failed to query: %v.", err)
+	}
+	rows.Close()
+
+	// Verify that a transaction reuses the cached statement.
+	tx, _ := db.Begin()
+	if _, err := conn.txExec(tx, "SELECT a"); err != nil {
+		t.Fatalf("TestStmtCache() - failed to execute in transaction: %v.", err)
+	}
+	tx.Commit()
+
+	fake.Lock()
+	tests := []struct {
+		query        string
+		wantPrepared int
+		wantClosed   int
+	}{
+		{"SELECT a", 1, 0},
+		{"SELECT b", 2, 1},
+		{"UPDATE c SET x = 1", 1, 1},
+	}
+	for i, test := range tests {
+		if have := fake.prepared[test.query]; have != test.wantPrepared {
+			t.Errorf("TestStmtCache()[%d] = %d, want %q prepared %d times.", i, have, test.query, test.wantPrepared)
+		}
+		if have := fake.closed[test.query]; have != test.wantClosed {
+			t.Errorf("TestStmtCache()[%d] = %d, want %q closed %d times.", i, have, test.query, test.wantClosed)
+		}
+	}
+	fake.Unlock()
+
+	// Verify that a schema change and closing the Connection purge the cache.
+	conn.exec("DROP TABLE c;")
+	if n := conn.stmts.order.Len(); n != 0 {
+		t.Errorf("TestStmtCache() = %d, want an empty cache after a schema change.", n)
+	}
+	conn.exec("SELECT a")
+	if err := conn.Close(); err != nil || conn.stmts.order.Len() != 0 {
+		t.Errorf("TestStmtCache() = %d (%v), want an empty cache after closing.", conn.stmts.order.Len(), err)
+	}
+}
+
+// TestStmtCacheInUse tests that a statement which is removed from the cache
+// while it is in use is closed only once it is released.
+func TestStmtCacheInUse(t *testing.T) {
+	db, err := sql.Open("structql-fake", "")
+	if err != nil {
+		t.Fatalf("Failed to open fake database: %v.", err)
+	}
+	defer db.Close()
+	cache := newStmtCache(1)
+
+	inUse, err := cache.acquire(db, "SELECT in_use")
+	if err != nil {
+		t.Fatalf("TestStmtCacheInUse() - failed to prepare: %v.", err)
+	}
+	other, err := cache.acquire(db, "SELECT evictor")
+	if err != nil {
+		t.Fatalf("TestStmtCacheInUse() - failed to prepare: %v.", err)
+	}
+	cache.release(other)
+
+	// The first statement has been evicted but must remain usable.
+	if _, err := inUse.stmt.Exec(); err != nil {
+		t.Errorf("TestStmtCacheInUse() = %v, want the evicted statement to remain open.", err)
+	}
+	cache.release(inUse)
+	if _, err := inUse.stmt.Exec(); err == nil {
+		t.Errorf("TestStmtCacheInUse() = nil, want the released statement to be closed.")
+	}
+}
+
+// TestStmtCacheConcurrent tests a full statement cache under concurrent use;
+// run it with -race.
+func TestStmtCacheConcurrent(t *testing.T) {
+	db, err := sql.Open("structql-fake", "")
+	if err != nil {
+		t.Fatalf("Failed to open fake database: %v.", err)
+	}
+	conn := &Connection{db: db, stmts: newStmtCache(1)}
+	defer conn.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16*200*2)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				query := fmt.Sprintf("SELECT concurrent_%d", (g+i)%8)
+				if _, err := conn.exec(query); err != nil {
+					errs <- err
+				}
+				rows, err := conn.query(query)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				for rows.Next() {
+				}
+				rows.Close()
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("TestStmtCacheConcurrent() = %v, want no error.", err)
+	}
+}
+
+// TestStmtCacheLiteral tests that queries with a formatted conditional bypass
+// the statement cache.
+func TestStmtCacheLiteral(t *testing.T) {
+	type Person struct {
+		ID int32 `sql:"id"`
+	}
+
+	db, err := sql.Open("structql-fake", "")
+	if err != nil {
+		t.Fatalf("Failed to open fake database: %v.", err)
+	}
+	conn := &Connection{db: db, softDeletes: &softDeletes{columns: map[string]string{}}, stmts: newStmtCache(4)}
+	defer conn.Close()
+
+	for i := 0; i < 8; i++ {
+		if _, err := conn.SelectFromWhere(Person{}, "literal_people", "id = %d", i); err != nil {
+			t.Fatalf("TestStmtCacheLiteral() - failed to select: %v.", err)
+		}
+	}
+	if n := conn.stmts.order.Len(); n != 0 {
+		t.Errorf("TestStmtCacheLiteral() = %d, want no cached statements after formatted queries.", n)
+	}
+	if _, err := conn.SelectFrom(Person{}, "literal_people"); err != nil {
+		t.Fatalf("TestStmtCacheLiteral() - failed to select: %v.", err)
+	}
+	if n := conn.stmts.order.Len(); n != 1 {
+		t.Errorf("TestStmtCacheLiteral() = %d, want 1 cached statement after a static query.", n)
+	}
+}
+
+// TestIsCacheable tests the isCacheable() and isSchemaChange() functions.
+func TestIsCacheable(t *testing.T) {
+	tests := []struct {
+		query            string
+		wantCacheable    bool
+		wantSchemaChange bool
+	}{
+		{"SELECT * FROM people;", true, false},
+		{"  insert INTO people (name) VALUES ($1);", true, false},
+		{"WITH t AS (SELECT 1) SELECT * FROM t;", true, false},
+		{"CREATE TABLE IF NOT EXISTS people (id INT);", false, true},
+		{"DO $$ BEGIN CREATE TYPE mood AS ENUM ('sad'); END $$;", false, true},
+		{"BEGIN;", false, false},
+		{"", false, false},
+	}
+	for i, test := range tests {
+		if have := isCacheable(test.query); have != test.wantCacheable {
+			t.Errorf("TestIsCacheable()[%d] = %t, want cacheable = %t.", i, have, test.wantCacheable)
+		}
+		if have := isSchemaChange(test.query); have != test.wantSchemaChange {
+			t.Errorf("TestIsCacheable()[%d] = %t, want schema change = %t.", i, have, test.wantSchemaChange)
+		}
+	}
+}
+
+// TestIsStaleStmt tests the isStaleStmt() function.
+func TestIsStaleStmt(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&pq.Error{Code: "0A000", Message: "cached plan must not change result type"}, true},
+		{&pq.Error{Code: "0A000", Message: "something else"}, false},
+		{&pq.Error{Code: "42P01"}, true},
+		{&pq.Error{Code: "23505"}, false},
+		{&mysql.MySQLError{Number: 1615}, true},
+		{errors.New("other"), false},
+		{nil, false},
+	}
+	for i, test := range tests {
+		if have := isStaleStmt(test.err); have != test.want {
+			t.Errorf("TestIsStaleStmt()[%d] = %t, want %t.", i, have, test.want)
+		}
+	}
+}
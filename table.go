@@ -1,7 +1,9 @@
 package structql
 
 import (
+	"database/sql"
 	"fmt"
+	"net"
 	"reflect"
 	"strings"
 	"time"
@@ -22,7 +24,15 @@ type Count struct {
 //     - The "sql" tag denotes the column name (e.g., "id").
 //     - The "typ" tag denotes the column type (e.g., "SERIAL").
 //     - The "opt" tag denotes column constraints (e.g., "PRIMARY KEY").
-//  2. One field must be a 32-bit integer that corresponds to the "id" column.
+//  2. At least one field belongs to the primary key.  Key fields carry the
+//     "pk" option in their "sql" tag (e.g., `sql:"user_id,pk"`) or declare a
+//     PRIMARY KEY constraint in their "opt" tag.  If no key is declared, the
+//     field corresponding to the "id" column is used as the key.
+// Anonymous embedded structures and nested structures with a "prefix" tag
+// contribute their fields as columns of the table (see getColumns()).  Fields
+// with an "enum" tag hold values of a registered enumeration (see RegisterEnum())
+// and fields with an "auto" tag hold timestamps that are maintained by the
+// database (see auto.go).
 func (conn *Connection) CreateTableFromObject(table string, object interface{}) error {
 	template := reflect.TypeOf(object)
 
@@ -31,58 +41,122 @@ func (conn *Connection) CreateTableFromObject(table string, object interface{})
 		return fmt.Errorf("type %s is not a structure", template.Name())
 	}
 
-	// match reports whether the field with the provided name is an ID column.
-	match := func(name string) bool {
-		field, _ := template.FieldByName(name)
-		return field.Tag.Get("sql") == "id"
+	// Verify that the object declares a primary key.
+	keys, err := primaryKey(template)
+	if err != nil {
+		return err
 	}
 
-	// Verify that the object contains an ID column.
-	if _, ok := template.FieldByNameFunc(match); !ok {
-		return fmt.Errorf("structure %s does not have a field for the ID column", template.Name())
+	// Collect the key columns that are not declared with an inline constraint.
+	keyCols := make([]string, 0, len(keys))
+	inline := 0
+	for _, key := range keys {
+		if hasInlineKey(key.field) {
+			inline++
+			continue
+		}
+		if key.opts.has("pk") {
+			keyCols = append(keyCols, key.name)
+		}
+	}
+	if inline > 0 && len(keys) > 1 {
+		return fmt.Errorf("structure %s declares a composite primary key with a PRIMARY KEY column constraint; use the \"pk\" tag option instead", template.Name())
 	}
 
-	// Construct a slice that holds the SQL table headers.
-	headers := make([]string, 0, template.NumField())
+	// Derive the columns of the SQL table, including those of nested structures.
+	cols, untagged := getColumns(template)
+	if err := conn.mapping.reportUntagged(template.Name(), untagged); err != nil {
+		return err
+	}
 
-	for i := 0; i < template.NumField(); i++ {
-		field := template.Field(i)
+	// Construct a slice that holds the SQL table headers.
+	headers := make([]string, 0, len(cols)+1)
 
-		// Derive the name of the SQL column.
-		sql, ok := field.Tag.Lookup("sql")
-		if !ok {
-			logger.Warning("Field %q in structure %s does not have an SQL column tag.", field.Name, template.Name())
-			continue
-		}
+	for _, col := range cols {
+		opt := col.field.Tag.Get("opt")
 
 		// Derive the type of the SQL column.
-		typ, err := getColumnType(field)
+		typ, err := getColumnType(col.field)
+		if enum, ok := col.field.Tag.Lookup("enum"); ok {
+			var check string
+			if typ, check, err = conn.enumColumn(enum, col.name); err != nil {
+				return fmt.Errorf("failed to declare column %q: %w", col.name, err)
+			}
+			opt = strings.TrimSpace(opt + " " + check)
+		}
 		if err != nil {
-			logger.Warning("Field %q in structure %s does not have a PostgreSQL type: %v.", field.Name, template.Name(), err)
+			if err := conn.mapping.report("Field %q in structure %s does not have a PostgreSQL type: %v", col.field.Name, template.Name(), err); err != nil {
+				return err
+			}
 			continue
 		}
 
+		// Mirror the validation rules of the column as a CHECK constraint.
+		if col.opts.has("check") {
+			check, err := conn.checkConstraint(col)
+			if err != nil {
+				return fmt.Errorf("failed to declare column %q: %w", col.name, err)
+			}
+			opt = strings.TrimSpace(opt + " " + check)
+		}
+
+		// Soft-deleted rows record the time of their deletion.
+		if col.opts.has("softdelete") && col.field.Type != timeType {
+			return fmt.Errorf("soft-delete column %q must have type time.Time", col.name)
+		}
+
+		// Let the database maintain the timestamps of auto columns.
+		if _, ok := col.field.Tag.Lookup("auto"); ok {
+			if opt, err = conn.autoColumn(col, opt); err != nil {
+				return fmt.Errorf("failed to declare column %q: %w", col.name, err)
+			}
+		}
+
 		// Construct a column header from the column name, type, and constraints.
-		header := fmt.Sprintf("%s %s %s", sql, typ, field.Tag.Get("opt"))
+		header := fmt.Sprintf("%s %s %s", col.name, typ, opt)
 		headers = append(headers, header)
 	}
 
+	// Declare the primary key as a table constraint so that it may span several columns.
+	if len(keyCols) > 0 {
+		headers = append(headers, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(keyCols, ", ")))
+	}
+
 	// Create the table (if it does not already exist).
 	schema := strings.Join(headers, ", ")
 	stmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s);", table, schema)
 	logger.SQL(stmt)
-	_, err := conn.exec(stmt)
-	if err == nil {
-		logger.SQL("Table created successfully ")
+	if _, err = conn.exec(stmt); err != nil {
+		return err
 	}
-	return err
+
+	// Remember the soft-delete column of the table.
+	conn.deletedColumn(table, cols)
+
+	// Maintain the "update" auto columns of rows that are updated by any statement.
+	if conn.driver == Postgres {
+		for _, col := range cols {
+			if autoMode(col) != autoUpdate {
+				continue
+			}
+			if err := conn.createAutoTrigger(table, col.name); err != nil {
+				return err
+			}
+		}
+	}
+
+	logger.SQL("Table created successfully ")
+	return nil
 }
 
 // getColumnType derives the PostgreSQL type of the given structure field.
+// Fields of type time.Time map to TIMESTAMP columns unless their "sql" tag
+// carries the "tz" option, in which case they map to TIMESTAMPTZ columns.
 func getColumnType(field reflect.StructField) (string, error) {
 	if typ, ok := field.Tag.Lookup("typ"); ok {
 		return typ, nil
 	}
+	_, opts, _ := parseTag(field)
 
 	var typ string
 	switch field.Type {
@@ -102,6 +176,25 @@ func getColumnType(field reflect.StructField) (string, error) {
 		typ = "TEXT"
 	case reflect.TypeOf(time.Time{}):
 		typ = "TIMESTAMP"
+		if opts.has("tz") {
+			typ = "TIMESTAMPTZ"
+		}
+	case reflect.TypeOf(time.Duration(0)):
+		typ = "INTERVAL"
+	case reflect.TypeOf(Date{}):
+		typ = "DATE"
+	case reflect.TypeOf(TimeOfDay{}):
+		typ = "TIME"
+	case reflect.TypeOf(Decimal{}):
+		typ = "NUMERIC"
+	case reflect.TypeOf(net.IP{}):
+		typ = "INET"
+	case reflect.TypeOf(net.IPNet{}):
+		typ = "CIDR"
+	case reflect.TypeOf(Point{}):
+		typ = "POINT"
+	case reflect.TypeOf(TimeRange{}):
+		typ = "TSTZRANGE"
 	case reflect.TypeOf([]byte{}):
 		typ = "BYTEA"
 	default:
@@ -118,63 +211,112 @@ func (conn *Connection) DropTable(table string) error {
 }
 
 // CountRows accepts a table name and returns the number of rows in that table.
+//
+// Soft-deleted rows are not counted unless the Connection receiver was obtained
+// from WithDeleted().  Note that CountRows() is not given a structure, so it
+// only knows the soft-delete column of a table once the table has been created
+// with CreateTableFromObject() or queried with a structure declaring the column
+// on this Connection, or once the column is registered with
+// RegisterSoftDelete().  Until then, soft-deleted rows ARE counted; use
+// CountObjects() to derive the column from the tags of a structure instead.
 func (conn *Connection) CountRows(table string) (int64, error) {
-	stmt := fmt.Sprintf("SELECT COUNT(*) FROM %s;", table)
+	return conn.count(table, nil, "")
+}
 
-	rows, err := conn.query(stmt)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get row count for table %x: %v", table, err)
-	}
+// CountRowsWhere accepts a table name and condition statement
+// and returns the number of rows in that table that meet the condition
+// (excluding soft-deleted rows, subject to the same caveat as CountRows).
+func (conn *Connection) CountRowsWhere(table string, cond string) (int64, error) {
+	return conn.count(table, nil, cond)
+}
 
-	cnt, err := parseResponse(rows, Count{})
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse count response: %v", err)
+// CountObjects returns the number of rows in the given table.  Unlike
+// CountRows(), the soft-delete column of the table is derived from the tags of
+// the given object, so soft-deleted rows are never counted (unless the
+// Connection receiver was obtained from WithDeleted()).
+func (conn *Connection) CountObjects(object interface{}, table string) (int64, error) {
+	template := reflect.TypeOf(object)
+	if template == nil || template.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("type %T is not a structure", object)
 	}
+	fields, _ := getColumns(template)
+	return conn.count(table, fields, "")
+}
 
-	// Extract Count from parsed struct
-	val := cnt[0].(Count).Count
-
-	return val, nil
+// CountObjectsWhere behaves like CountObjects() but only counts the rows that
+// meet the given condition.
+func (conn *Connection) CountObjectsWhere(object interface{}, table string, cond string) (int64, error) {
+	template := reflect.TypeOf(object)
+	if template == nil || template.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("type %T is not a structure", object)
+	}
+	fields, _ := getColumns(template)
+	return conn.count(table, fields, cond)
 }
 
-// CountRowsWhere accepts a table name and condition statement
-// and returns the number of rows in that table that meet the condition
-func (conn *Connection) CountRowsWhere(table string, cond string) (int64, error) {
-	stmt := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s;", table, cond)
+// count returns the number of rows in the given table that meet the provided
+// condition and have not been soft-deleted according to the given columns or
+// the registered soft-delete column of the table.  An empty condition is met
+// by every row.
+func (conn *Connection) count(table string, cols []column, cond string) (int64, error) {
+	literal := cond != ""
+	stmt := fmt.Sprintf("SELECT COUNT(*) FROM %s;", table)
+	if cond = conn.excludeDeleted(table, cols, cond); cond != "" {
+		stmt = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s;", table, cond)
+	}
 
-	rows, err := conn.query(stmt)
+	// A given conditional embeds its values, so the statement cache is bypassed.
+	var rows *sql.Rows
+	var err error
+	if literal {
+		rows, err = conn.queryLiteral(stmt)
+	} else {
+		rows, err = conn.query(stmt)
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to get row count for table %x: %v", table, err)
+		return 0, fmt.Errorf("failed to get row count for table %x: %w", table, err)
 	}
 
-	cnt, err := parseResponse(rows, Count{})
+	cnt, err := parseResponse(rows, Count{}, conn.mapping)
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse count response: %v", err)
+		return 0, fmt.Errorf("failed to parse count response: %w", err)
 	}
 
 	// Extract Count from parsed struct
+	if len(cnt) == 0 {
+		return 0, fmt.Errorf("count of table %q returned no rows: %w", table, ErrNotFound)
+	}
 	val := cnt[0].(Count).Count
 
 	return val, nil
 }
 
-// OldestEntry returns the oldest row in the given table
+// OldestEntry returns the oldest row in the given table that has not been
+// soft-deleted
 func (conn *Connection) OldestEntry(object interface{}, table string, timestampCol string) (interface{}, error) {
 
-	stmt := fmt.Sprintf("SELECT * FROM %s ORDER BY %s LIMIT 1", table, timestampCol)
+	var fields []column
+	if template := reflect.TypeOf(object); template != nil && template.Kind() == reflect.Struct {
+		fields, _ = getColumns(template)
+	}
+	cond := conn.excludeDeleted(table, fields, "")
+	stmt, err := conn.selectStmt("*", table, cond, newQueryOptions([]QueryOption{OrderBy(Asc(timestampCol)), Limit(1)}))
+	if err != nil {
+		return nil, err
+	}
 
 	rows, err := conn.query(stmt)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get oldest entry count for table %x sorting by %s: %v", table, timestampCol, err)
+		return nil, fmt.Errorf("failed to get oldest entry count for table %x sorting by %s: %w", table, timestampCol, err)
 	}
 
-	objects, err := parseResponse(rows, object)
+	objects, err := parseResponse(rows, object, conn.mapping)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse rows: %v", err)
+		return nil, fmt.Errorf("failed to parse rows: %w", err)
 	}
 
 	if len(objects) < 1 {
-		return nil, fmt.Errorf("no values in cache")
+		return nil, fmt.Errorf("no values in table %q: %w", table, ErrNotFound)
 	}
 
 	return objects[0], nil
@@ -1,6 +1,7 @@
 package structql
 
 import (
+	"net"
 	"reflect"
 	"testing"
 	"time"
@@ -79,6 +80,56 @@ func TestGetColumnType(t *testing.T) {
 			``,
 			"TIMESTAMP",
 			false,
+		}, {
+			reflect.TypeOf(time.Time{}),
+			`sql:"created_at,tz"`,
+			"TIMESTAMPTZ",
+			false,
+		}, {
+			reflect.TypeOf(time.Duration(0)),
+			``,
+			"INTERVAL",
+			false,
+		}, {
+			reflect.TypeOf(Date{}),
+			``,
+			"DATE",
+			false,
+		}, {
+			reflect.TypeOf(TimeOfDay{}),
+			``,
+			"TIME",
+			false,
+		}, {
+			reflect.TypeOf(Decimal{}),
+			``,
+			"NUMERIC",
+			false,
+		}, {
+			reflect.TypeOf(Decimal{}),
+			`typ:"NUMERIC(12,2)"`,
+			"NUMERIC(12,2)",
+			false,
+		}, {
+			reflect.TypeOf(net.IP{}),
+			``,
+			"INET",
+			false,
+		}, {
+			reflect.TypeOf(net.IPNet{}),
+			``,
+			"CIDR",
+			false,
+		}, {
+			reflect.TypeOf(Point{}),
+			``,
+			"POINT",
+			false,
+		}, {
+			reflect.TypeOf(TimeRange{}),
+			``,
+			"TSTZRANGE",
+			false,
 		}, {
 			reflect.TypeOf(time.Time{}),
 			`typ:"FAKENEWS"`,
@@ -154,6 +205,23 @@ func TestCreateDropTable(t *testing.T) {
 			},
 			`INSERT INTO tree (oak, dna) VALUES (true, '\\001\\002\\003')`,
 			false,
+		}, {
+			"membership",
+			struct {
+				UserID  int64  `sql:"user_id,pk"`
+				GroupID int64  `sql:"group_id,pk"`
+				Role    string `sql:"role"`
+			}{},
+			"INSERT INTO membership (user_id, group_id, role) VALUES (1, 2, 'owner')",
+			false,
+		}, {
+			"ambiguous",
+			struct {
+				UserID  int64 `sql:"user_id,pk" opt:"PRIMARY KEY"`
+				GroupID int64 `sql:"group_id,pk"`
+			}{},
+			"",
+			true,
 		},
 	}
 
@@ -0,0 +1,158 @@
+package structql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// tagOptions holds the comma-separated options that may follow the column name
+// in an "sql" tag.  For example, the tag `sql:"user_id,pk"` maps a field to the
+// "user_id" column and marks that column as part of the primary key.
+type tagOptions []string
+
+// has reports whether the given option is present in the tagOptions receiver.
+func (opts tagOptions) has(name string) bool {
+	for _, opt := range opts {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTag splits the "sql" tag of the given field into a column name and a
+// list of options.  The boolean result reports whether the tag is present.
+func parseTag(field reflect.StructField) (string, tagOptions, bool) {
+	tag, ok := field.Tag.Lookup("sql")
+	if !ok {
+		return "", nil, false
+	}
+
+	parts := strings.Split(tag, ",")
+	opts := make(tagOptions, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		if opt := strings.TrimSpace(part); opt != "" {
+			opts = append(opts, opt)
+		}
+	}
+	return strings.TrimSpace(parts[0]), opts, true
+}
+
+// column describes a (possibly nested) structure field that maps to an SQL column.
+type column struct {
+	// name is the name of the SQL column, including any prefixes.
+	name string
+	// index is the index sequence of the field for reflect.Value.FieldByIndex().
+	index []int
+	// field is the structure field itself.
+	field reflect.StructField
+	// opts are the options that follow the column name in the "sql" tag.
+	opts tagOptions
+}
+
+// getColumns returns the columns of the given structure type in field order.
+// Fields of anonymous embedded structures are flattened into the columns of the
+// enclosing structure, and fields of named nested structures carrying a
+// "prefix" tag are flattened with that prefix prepended to their column names:
+//
+//  type Address struct {
+//    City string `sql:"city"`
+//  }
+//
+//  type Person struct {
+//    Timestamps
+//    Name string  `sql:"name"`
+//    Addr Address `sql:"addr" prefix:"addr_"`
+//  }
+//
+// Here, the Person columns are those of Timestamps followed by "name" and
+// "addr_city".  The names of the untagged fields that were skipped are also
+// returned so that callers may report them.
+//
+// The columns of each structure type are derived once and cached (see
+// structInfoOf()), so the returned slices must not be modified.
+func getColumns(template reflect.Type) ([]column, []string) {
+	info := structInfoOf(template)
+	return info.cols[:len(info.cols):len(info.cols)], info.untagged[:len(info.untagged):len(info.untagged)]
+}
+
+// walkColumns derives the columns and untagged fields of the given structure
+// type as described by getColumns().
+func walkColumns(template reflect.Type) ([]column, []string) {
+	cols := []column{}
+	untagged := []string{}
+
+	var walk func(typ reflect.Type, index []int, prefix string, path string)
+	walk = func(typ reflect.Type, index []int, prefix string, path string) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			fieldIndex := append(append([]int{}, index...), i)
+			fieldPath := path + field.Name
+
+			// The Tracker of an object is managed separately (see track.go).
+			if field.Type == trackerType && field.Anonymous {
+				continue
+			}
+
+			name, opts, tagged := parseTag(field)
+			nested, hasPrefix := field.Tag.Lookup("prefix")
+
+			// Flatten embedded structures and named structures with a prefix.
+			isStruct := field.Type.Kind() == reflect.Struct
+			if isStruct && (hasPrefix || (field.Anonymous && !tagged)) {
+				walk(field.Type, fieldIndex, prefix+nested, fieldPath+".")
+				continue
+			}
+
+			// Unexported fields cannot be read or written through reflection.
+			if !tagged || field.PkgPath != "" {
+				untagged = append(untagged, fieldPath)
+				continue
+			}
+
+			cols = append(cols, column{prefix + name, fieldIndex, field, opts})
+		}
+	}
+	walk(template, nil, "", "")
+	return cols, untagged
+}
+
+// hasInlineKey reports whether the "opt" tag of the given field declares a
+// PRIMARY KEY column constraint.
+func hasInlineKey(field reflect.StructField) bool {
+	return strings.Contains(strings.ToUpper(field.Tag.Get("opt")), "PRIMARY KEY")
+}
+
+// primaryKey returns the columns that make up the primary key of the given
+// structure type.  A column belongs to the primary key if its "sql" tag
+// carries the "pk" option or its "opt" tag declares a PRIMARY KEY constraint.
+// If no column is marked in this way, the "id" column is used as the key.
+func primaryKey(template reflect.Type) ([]column, error) {
+	info := structInfoOf(template)
+	return info.keys[:len(info.keys):len(info.keys)], info.keyErr
+}
+
+// findPrimaryKey derives the primary key of the given structure type with the
+// provided columns as described by primaryKey().
+func findPrimaryKey(template reflect.Type, cols []column) ([]column, error) {
+	keys := []column{}
+	for _, col := range cols {
+		if col.opts.has("pk") || hasInlineKey(col.field) {
+			keys = append(keys, col)
+		}
+	}
+
+	if len(keys) == 0 {
+		for _, col := range cols {
+			if col.name == "id" {
+				keys = append(keys, col)
+				break
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("structure %s does not declare a primary key; tag a key field with `sql:\"<column>,pk\"`", template.Name())
+	}
+	return keys, nil
+}
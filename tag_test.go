@@ -0,0 +1,180 @@
+// Package structql implements the Database structure.
+// This file contains tests for tag.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestParseTag tests the parseTag() function.
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag      reflect.StructTag
+		wantCol  string
+		wantOpts tagOptions
+		wantOK   bool
+	}{
+		{
+			``,
+			"",
+			nil,
+			false,
+		}, {
+			`sql:"id"`,
+			"id",
+			tagOptions{},
+			true,
+		}, {
+			`sql:"user_id,pk"`,
+			"user_id",
+			tagOptions{"pk"},
+			true,
+		}, {
+			`sql:"user_id, pk,"`,
+			"user_id",
+			tagOptions{"pk"},
+			true,
+		},
+	}
+	for i, test := range tests {
+		field := reflect.StructField{Type: reflect.TypeOf(0), Tag: test.tag}
+		haveCol, haveOpts, haveOK := parseTag(field)
+		if haveCol != test.wantCol || haveOK != test.wantOK || !reflect.DeepEqual(haveOpts, test.wantOpts) {
+			t.Errorf("TestParseTag()[%d] = (%q, %v, %t), want (%q, %v, %t).", i, haveCol, haveOpts, haveOK, test.wantCol, test.wantOpts, test.wantOK)
+		}
+	}
+}
+
+// TestPrimaryKey tests the primaryKey() function.
+func TestPrimaryKey(t *testing.T) {
+	tests := []struct {
+		object   interface{}
+		wantKeys []string
+		wantErr  bool
+	}{
+		{
+			struct {
+				Name string `sql:"name"`
+			}{},
+			nil,
+			true,
+		}, {
+			struct {
+				Name string `sql:"name"`
+				ID   int32  `sql:"id"`
+			}{},
+			[]string{"id"},
+			false,
+		}, {
+			struct {
+				ID    int32  `sql:"id"`
+				Email string `sql:"email" opt:"PRIMARY KEY"`
+			}{},
+			[]string{"email"},
+			false,
+		}, {
+			struct {
+				UserID  int64  `sql:"user_id,pk"`
+				GroupID int64  `sql:"group_id,pk"`
+				Role    string `sql:"role"`
+			}{},
+			[]string{"user_id", "group_id"},
+			false,
+		},
+	}
+	for i, test := range tests {
+		keys, haveErr := primaryKey(reflect.TypeOf(test.object))
+		if (haveErr != nil) != test.wantErr {
+			t.Errorf("TestPrimaryKey()[%d] = %v, want error %t.", i, haveErr, test.wantErr)
+		}
+		var haveKeys []string
+		for _, key := range keys {
+			haveKeys = append(haveKeys, key.name)
+		}
+		if !reflect.DeepEqual(haveKeys, test.wantKeys) {
+			t.Errorf("TestPrimaryKey()[%d] = %v, want keys %v.", i, haveKeys, test.wantKeys)
+		}
+	}
+}
+
+// TestGetColumns tests the getColumns() function.
+func TestGetColumns(t *testing.T) {
+	type Timestamps struct {
+		Created time.Time `sql:"created_at"`
+		Updated time.Time `sql:"updated_at"`
+	}
+	type Address struct {
+		City   string `sql:"city"`
+		Street string `sql:"street"`
+	}
+	type Location struct {
+		Home Address `sql:"home" prefix:"home_"`
+	}
+
+	tests := []struct {
+		object       interface{}
+		wantCols     []string
+		wantIndices  [][]int
+		wantUntagged []string
+	}{
+		{
+			struct {
+				ID   int32 `sql:"id"`
+				Note string
+			}{},
+			[]string{"id"},
+			[][]int{{0}},
+			[]string{"Note"},
+		}, {
+			struct {
+				Timestamps
+				Name string `sql:"name"`
+			}{},
+			[]string{"created_at", "updated_at", "name"},
+			[][]int{{0, 0}, {0, 1}, {1}},
+			[]string{},
+		}, {
+			struct {
+				Name string  `sql:"name"`
+				Addr Address `sql:"addr" prefix:"addr_"`
+			}{},
+			[]string{"name", "addr_city", "addr_street"},
+			[][]int{{0}, {1, 0}, {1, 1}},
+			[]string{},
+		}, {
+			struct {
+				Loc Location `prefix:"loc_"`
+			}{},
+			[]string{"loc_home_city", "loc_home_street"},
+			[][]int{{0, 0, 0}, {0, 0, 1}},
+			[]string{},
+		}, {
+			struct {
+				Seen time.Time `sql:"seen"`
+			}{},
+			[]string{"seen"},
+			[][]int{{0}},
+			[]string{},
+		},
+	}
+	for i, test := range tests {
+		cols, haveUntagged := getColumns(reflect.TypeOf(test.object))
+		haveCols := []string{}
+		haveIndices := [][]int{}
+		for _, col := range cols {
+			haveCols = append(haveCols, col.name)
+			haveIndices = append(haveIndices, col.index)
+		}
+		if !reflect.DeepEqual(haveCols, test.wantCols) {
+			t.Errorf("TestGetColumns()[%d] = %v, want columns %v.", i, haveCols, test.wantCols)
+		}
+		if !reflect.DeepEqual(haveIndices, test.wantIndices) {
+			t.Errorf("TestGetColumns()[%d] = %v, want indices %v.", i, haveIndices, test.wantIndices)
+		}
+		if !reflect.DeepEqual(haveUntagged, test.wantUntagged) {
+			t.Errorf("TestGetColumns()[%d] = %v, want untagged fields %v.", i, haveUntagged, test.wantUntagged)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package structql
+
+import (
+	"reflect"
+)
+
+// Tracker enables change tracking for the structure that embeds it.  Objects
+// with an embedded Tracker that are loaded from the database (e.g., through
+// SelectFromWhere()) remember the values of their columns, and UpdateObject()
+// then writes only the columns whose values have changed since:
+//
+//  type Person struct {
+//    structql.Tracker
+//    ID   int32  `sql:"id" opt:"PRIMARY KEY"`
+//    Name string `sql:"name"`
+//    Age  int32  `sql:"age"`
+//  }
+//
+//  people, err := conn.SelectFromWhere(Person{}, "people", "id = 1")
+//  person := people[0].(Person)
+//  person.Age++
+//  err = conn.UpdateObject("people", &person) // Only writes the "age" column.
+//
+// Passing a pointer to UpdateObject() refreshes the remembered values so that
+// later updates of the same object are tracked from that point onwards.
+type Tracker struct {
+	// snapshot maps each column name to its encoded value when it was loaded.
+	snapshot map[string]interface{}
+}
+
+// trackerType is the reflected type of the Tracker structure.
+var trackerType = reflect.TypeOf(Tracker{})
+
+// trackerIndex returns the index sequence of the Tracker embedded in the given
+// structure type.  The boolean result reports whether a Tracker is embedded.
+func trackerIndex(template reflect.Type) ([]int, bool) {
+	field, ok := template.FieldByName(trackerType.Name())
+	if !ok || field.Type != trackerType || !field.Anonymous {
+		return nil, false
+	}
+	return field.Index, true
+}
+
+// track stores the current values of the given columns in the Tracker that is
+// embedded in the provided object, if any.
+func track(objVal reflect.Value, cols []column) {
+	index, ok := trackerIndex(objVal.Type())
+	if !ok {
+		return
+	}
+
+	snapshot := make(map[string]interface{}, len(cols))
+	for _, col := range cols {
+		if val, err := encodeValue(col, objVal.FieldByIndex(col.index)); err == nil {
+			snapshot[col.name] = val
+		}
+	}
+	objVal.FieldByIndex(index).Set(reflect.ValueOf(Tracker{snapshot}))
+}
+
+// changed reports whether the given encoded value of a column differs from the
+// value that was recorded by the Tracker embedded in the provided object.  If
+// the object does not embed a Tracker or was not loaded from the database,
+// every column is considered to have changed.
+func changed(objVal reflect.Value, col column, val interface{}) bool {
+	index, ok := trackerIndex(objVal.Type())
+	if !ok {
+		return true
+	}
+	snapshot := objVal.FieldByIndex(index).Interface().(Tracker).snapshot
+	if snapshot == nil {
+		return true
+	}
+	old, ok := snapshot[col.name]
+	return !ok || !reflect.DeepEqual(old, val)
+}
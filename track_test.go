@@ -0,0 +1,123 @@
+// Package structql implements the Database structure.
+// This file contains tests for track.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestTrack tests the track() and changed() functions.
+func TestTrack(t *testing.T) {
+	type Person struct {
+		Tracker
+		ID   int32  `sql:"id"`
+		Name string `sql:"name"`
+		Age  int32  `sql:"age"`
+	}
+
+	cols, untagged := getColumns(reflect.TypeOf(Person{}))
+	if len(cols) != 3 || len(untagged) != 0 {
+		t.Fatalf("TestTrack() = %d columns and %v untagged fields, want 3 columns and none untagged.", len(cols), untagged)
+	}
+
+	person := Person{ID: 1, Name: "Ada", Age: 36}
+	value := reflect.ValueOf(&person).Elem()
+
+	// An object that was never loaded has changed in every column.
+	for _, col := range cols {
+		val, _ := encodeValue(col, value.FieldByIndex(col.index))
+		if !changed(value, col, val) {
+			t.Errorf("TestTrack() - column %q of an untracked object is unchanged.", col.name)
+		}
+	}
+
+	track(value, cols)
+	person.Age = 37
+
+	for _, col := range cols {
+		val, _ := encodeValue(col, value.FieldByIndex(col.index))
+		if have, want := changed(value, col, val), col.name == "age"; have != want {
+			t.Errorf("TestTrack() - column %q changed = %t, want %t.", col.name, have, want)
+		}
+	}
+}
+
+// TestUpdateFieldsUnwritable tests that (*Connection).UpdateFields() rejects the
+// columns that UpdateObject() never writes.
+func TestUpdateFieldsUnwritable(t *testing.T) {
+	type Invoice struct {
+		ID      int32     `sql:"id" opt:"PRIMARY KEY"`
+		Number  int64     `sql:"number" typ:"BIGSERIAL"`
+		Total   int64     `sql:"total"`
+		Version int64     `sql:"version,version"`
+		Deleted time.Time `sql:"deleted_at,softdelete"`
+		Updated time.Time `sql:"updated_at" auto:"update"`
+	}
+
+	conn := &Connection{}
+	for i, name := range []string{"id", "number", "version", "deleted_at", "updated_at"} {
+		if err := conn.UpdateFields("invoices", Invoice{ID: 1}, "total", name); err == nil {
+			t.Errorf("TestUpdateFieldsUnwritable()[%d] - updated column %q.", i, name)
+		}
+	}
+}
+
+// TestUpdateFields tests the (*Connection).UpdateFields() method and the
+// change tracking of (*Connection).UpdateObject().
+func TestUpdateFields(t *testing.T) {
+	type Person struct {
+		Tracker
+		ID   int32  `sql:"id" opt:"PRIMARY KEY"`
+		Name string `sql:"name"`
+		Age  int32  `sql:"age"`
+	}
+
+	conn := createTableUnsafe("People", Person{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	if _, err := conn.InsertObject("People", Person{ID: 1, Name: "Ada", Age: 36}); err != nil {
+		t.Fatalf("Failed to insert Person: %v.", err)
+	}
+
+	// Load two copies of the same person and modify different columns.
+	load := func() Person {
+		people, err := conn.SelectFromWhere(Person{}, "People", "id = 1")
+		if err != nil || len(people) != 1 {
+			t.Fatalf("TestUpdateFields() - failed to select Person: %v.", err)
+		}
+		return people[0].(Person)
+	}
+	first, second := load(), load()
+	first.Name = "Augusta"
+	second.Age = 37
+
+	if err := conn.UpdateObject("People", &first); err != nil {
+		t.Fatalf("TestUpdateFields() - failed to update Person: %v.", err)
+	}
+	if err := conn.UpdateObject("People", &second); err != nil {
+		t.Fatalf("TestUpdateFields() - failed to update Person: %v.", err)
+	}
+	if have := load(); have.Name != "Augusta" || have.Age != 37 {
+		t.Errorf("TestUpdateFields() = %v, want both tracked changes.", have)
+	}
+
+	// Update a single column of an untracked copy.
+	untracked := Person{ID: 1, Name: "Lovelace", Age: 0}
+	if err := conn.UpdateFields("People", untracked, "name"); err != nil {
+		t.Fatalf("TestUpdateFields() - failed to update fields: %v.", err)
+	}
+	if have := load(); have.Name != "Lovelace" || have.Age != 37 {
+		t.Errorf("TestUpdateFields() = %v, want only the name to change.", have)
+	}
+
+	// Verify that unknown and key columns are rejected.
+	if err := conn.UpdateFields("People", untracked, "mass"); err == nil {
+		t.Errorf("TestUpdateFields() - updated an unknown column.")
+	}
+	if err := conn.UpdateFields("People", untracked, "id"); err == nil {
+		t.Errorf("TestUpdateFields() - updated a key column.")
+	}
+}
@@ -0,0 +1,433 @@
+package structql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Decimal is an exact decimal number that maps to a NUMERIC column.  Unlike
+// float32 and float64, a Decimal preserves every digit of the stored value,
+// which makes it suitable for money and measurements.  The precision and scale
+// of the column can be specified with the "typ" tag:
+//
+//  type Invoice struct {
+//    Total Decimal `sql:"total" typ:"NUMERIC(12,2)"`
+//  }
+//
+// The zero value of a Decimal is 0.
+type Decimal struct {
+	// value is the canonical string representation of the Decimal.
+	value string
+}
+
+// decimalPattern matches the textual representation of a decimal number.
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+
+// ParseDecimal parses the given string (e.g., "-12.50") into a Decimal.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return Decimal{}, fmt.Errorf("%q is not a decimal number", s)
+	}
+
+	// Separate the sign from the digits.
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+
+	// Canonicalise the integer and fractional parts.
+	parts := strings.SplitN(s, ".", 2)
+	whole := strings.TrimLeft(parts[0], "0")
+	if whole == "" {
+		whole = "0"
+	}
+	value := whole
+	if len(parts) == 2 && parts[1] != "" {
+		value += "." + parts[1]
+	}
+
+	// Represent an integral zero with the zero value so that the two compare equal.
+	if value == "0" {
+		return Decimal{}, nil
+	}
+	if neg && strings.Trim(value, "0.") != "" {
+		value = "-" + value
+	}
+	return Decimal{value}, nil
+}
+
+// NewDecimal returns the Decimal with the value unscaled * 10^-scale.  For
+// example, NewDecimal(1250, 2) represents 12.50.
+func NewDecimal(unscaled int64, scale int) Decimal {
+	digits := strconv.FormatInt(unscaled, 10)
+	neg := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+	if scale > 0 {
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	} else if unscaled != 0 {
+		digits += strings.Repeat("0", -scale)
+	}
+	if neg {
+		digits = "-" + digits
+	}
+	d, _ := ParseDecimal(digits)
+	return d
+}
+
+// String returns the decimal representation of the Decimal receiver.
+func (d Decimal) String() string {
+	if d.value == "" {
+		return "0"
+	}
+	return d.value
+}
+
+// Rat returns the value of the Decimal receiver as an exact rational number.
+func (d Decimal) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(d.String())
+	return r
+}
+
+// Float64 returns the nearest float64 value of the Decimal receiver.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Value implements the driver.Valuer interface.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, so that a
+// Decimal is encoded as a JSON string without a loss of precision.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	dec, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = dec
+	return nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (d *Decimal) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case []byte:
+		s = string(src)
+	case string:
+		s = src
+	case int64:
+		s = strconv.FormatInt(src, 10)
+	case float64:
+		s = strconv.FormatFloat(src, 'f', -1, 64)
+	default:
+		return fmt.Errorf("cannot scan type %T into a Decimal", src)
+	}
+
+	dec, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = dec
+	return nil
+}
+
+// Date is a calendar date without a time of day that maps to a DATE column.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the Date on which the given time occurs in its location.
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{year, month, day}
+}
+
+// Time returns the time at midnight of the Date receiver in the given location.
+func (d Date) Time(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// String returns the Date receiver in the ISO 8601 format (e.g., "2006-01-02").
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// Value implements the driver.Valuer interface.
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (d *Date) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*d = Date{}
+		return nil
+	case time.Time:
+		*d = DateOf(src)
+		return nil
+	case []byte:
+		return d.Scan(string(src))
+	case string:
+		t, err := time.Parse("2006-01-02", src)
+		if err != nil {
+			return fmt.Errorf("failed to parse date %q: %w", src, err)
+		}
+		*d = DateOf(t)
+		return nil
+	}
+	return fmt.Errorf("cannot scan type %T into a Date", src)
+}
+
+// TimeOfDay is a time of day without a date that maps to a TIME column.
+type TimeOfDay struct {
+	Hour       int
+	Minute     int
+	Second     int
+	Nanosecond int
+}
+
+// TimeOfDayOf returns the TimeOfDay at which the given time occurs in its
+// location.
+func TimeOfDayOf(t time.Time) TimeOfDay {
+	return TimeOfDay{t.Hour(), t.Minute(), t.Second(), t.Nanosecond()}
+}
+
+// String returns the TimeOfDay receiver in the ISO 8601 format (e.g.,
+// "15:04:05" or "15:04:05.5").
+func (t TimeOfDay) String() string {
+	return time.Date(0, time.January, 1, t.Hour, t.Minute, t.Second, t.Nanosecond, time.UTC).Format("15:04:05.999999999")
+}
+
+// Value implements the driver.Valuer interface.
+func (t TimeOfDay) Value() (driver.Value, error) {
+	return t.String(), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (t *TimeOfDay) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*t = TimeOfDay{}
+		return nil
+	case time.Time:
+		*t = TimeOfDayOf(src)
+		return nil
+	case []byte:
+		return t.Scan(string(src))
+	case string:
+		parsed, err := time.Parse("15:04:05.999999999", src)
+		if err != nil {
+			return fmt.Errorf("failed to parse time of day %q: %w", src, err)
+		}
+		*t = TimeOfDayOf(parsed)
+		return nil
+	}
+	return fmt.Errorf("cannot scan type %T into a TimeOfDay", src)
+}
+
+// interval scans a PostgreSQL INTERVAL into a time.Duration.  As in PostgreSQL,
+// a day is taken to be 24 hours, a month 30 days, and a year 365.25 days.
+type interval time.Duration
+
+// intervalUnits maps the units of a PostgreSQL interval to their durations.
+var intervalUnits = map[string]time.Duration{
+	"year":  time.Duration(365.25 * 24 * float64(time.Hour)),
+	"years": time.Duration(365.25 * 24 * float64(time.Hour)),
+	"mon":   30 * 24 * time.Hour,
+	"mons":  30 * 24 * time.Hour,
+	"day":   24 * time.Hour,
+	"days":  24 * time.Hour,
+}
+
+// Scan implements the sql.Scanner interface.
+func (i *interval) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*i = 0
+		return nil
+	case []byte:
+		d, err := parseInterval(string(src))
+		*i = interval(d)
+		return err
+	case string:
+		d, err := parseInterval(src)
+		*i = interval(d)
+		return err
+	case int64:
+		*i = interval(src)
+		return nil
+	}
+	return fmt.Errorf("cannot scan type %T into a time.Duration", src)
+}
+
+// parseInterval parses an interval in the default "postgres" IntervalStyle
+// (e.g., "1 year 2 mons -3 days +04:05:06.5") into a time.Duration.
+func parseInterval(s string) (time.Duration, error) {
+	var total time.Duration
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		// Parse a [+-]HH:MM:SS[.ffffff] clock component.
+		if strings.Contains(field, ":") {
+			neg := strings.HasPrefix(field, "-")
+			parts := strings.Split(strings.TrimLeft(field, "+-"), ":")
+			if len(parts) != 3 {
+				return 0, fmt.Errorf("failed to parse interval %q", s)
+			}
+			hours, err1 := strconv.ParseInt(parts[0], 10, 64)
+			mins, err2 := strconv.ParseInt(parts[1], 10, 64)
+			secs, err3 := strconv.ParseFloat(parts[2], 64)
+			if err1 != nil || err2 != nil || err3 != nil {
+				return 0, fmt.Errorf("failed to parse interval %q", s)
+			}
+			clock := time.Duration(hours)*time.Hour + time.Duration(mins)*time.Minute + time.Duration(secs*float64(time.Second)+0.5)
+			if neg {
+				clock = -clock
+			}
+			total += clock
+			continue
+		}
+
+		// Parse a "<quantity> <unit>" component.
+		if i+1 >= len(fields) {
+			return 0, fmt.Errorf("failed to parse interval %q", s)
+		}
+		n, err := strconv.ParseInt(field, 10, 64)
+		unit, ok := intervalUnits[fields[i+1]]
+		if err != nil || !ok {
+			return 0, fmt.Errorf("failed to parse interval %q", s)
+		}
+		total += time.Duration(n) * unit
+		i++
+	}
+	return total, nil
+}
+
+// formatInterval formats the given time.Duration as a PostgreSQL interval.
+func formatInterval(d time.Duration) string {
+	return fmt.Sprintf("%d microseconds", d/time.Microsecond)
+}
+
+// Define the reflected types that receive special treatment from the encoders
+// and decoders below.
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	scannerType  = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// encodeValue returns the value of the given column in a form that is suitable
+// for the database driver.  Values of enumerated columns are validated against
+// their registered enumeration.
+func encodeValue(col column, val reflect.Value) (interface{}, error) {
+	if name, ok := col.field.Tag.Lookup("enum"); ok {
+		label, err := encodeEnum(name, val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for column %q: %w", col.name, err)
+		}
+		return label, nil
+	}
+	if val.Type() == durationType && hasNativeType(col, "INTERVAL") {
+		return formatInterval(time.Duration(val.Int())), nil
+	}
+	if hasNativeType(col, "INET", "CIDR") {
+		if network, ok := encodeNetwork(val); ok {
+			return network, nil
+		}
+	}
+	return val.Interface(), nil
+}
+
+// hasNativeType reports whether the given column has one of the provided SQL
+// types or, lacking a "typ" tag, the type that getColumnType() derives for its
+// field.  Columns whose type is overridden (e.g., a time.Duration stored in a
+// BIGINT column) are encoded and decoded as their plain Go values.
+func hasNativeType(col column, types ...string) bool {
+	typ, ok := col.field.Tag.Lookup("typ")
+	if !ok {
+		return true
+	}
+	typ = strings.ToUpper(strings.TrimSpace(typ))
+	for _, name := range types {
+		if strings.HasPrefix(typ, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// newScanDest returns a scan destination for the given column, provided that
+// the type of the column field requires custom decoding.
+func newScanDest(col column) (interface{}, bool) {
+	typ := col.field.Type
+	if name, ok := col.field.Tag.Lookup("enum"); ok && isInteger(typ) {
+		return &enumIndex{name: name}, true
+	}
+	switch {
+	case typ == timeType && col.opts.has("softdelete"):
+		return new(nullTime), true
+	case typ == durationType && hasNativeType(col, "INTERVAL"):
+		return new(interval), true
+	case typ == ipType && hasNativeType(col, "INET", "CIDR"):
+		return new(ipAddr), true
+	case typ == ipNetType && hasNativeType(col, "INET", "CIDR"):
+		return new(ipNet), true
+	case reflect.PtrTo(typ).Implements(scannerType):
+		return reflect.New(typ).Interface(), true
+	}
+	return nil, false
+}
+
+// decodeValue converts the given scanned entry into a value that can be
+// assigned to a field of the given type.  Integers and floating-point numbers
+// are converted to the width of the field, and times are normalised to UTC so
+// that TIMESTAMP and TIMESTAMPTZ columns yield comparable values.
+func decodeValue(entry reflect.Value, typ reflect.Type) reflect.Value {
+	if index, ok := entry.Interface().(enumIndex); ok {
+		entry = reflect.ValueOf(index.index)
+	}
+	if entry.Type() != typ && sameClass(entry.Type(), typ) {
+		entry = entry.Convert(typ)
+	}
+	if entry.Type() == timeType {
+		entry = reflect.ValueOf(entry.Interface().(time.Time).UTC())
+	}
+	return entry
+}
+
+// sameClass reports whether values of the two given types can be converted
+// into one another without changing their meaning.
+func sameClass(a reflect.Type, b reflect.Type) bool {
+	isFloat := func(typ reflect.Type) bool {
+		return typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64
+	}
+	switch {
+	case isInteger(a) && isInteger(b), isFloat(a) && isFloat(b):
+		return true
+	}
+	return a.Kind() == b.Kind() && a.ConvertibleTo(b)
+}
@@ -0,0 +1,241 @@
+// Package structql implements the Database structure.
+// This file contains tests for types.go.
+package structql
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestParseDecimal tests the ParseDecimal() function.
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"0", "0", false},
+		{"-0", "0", false},
+		{"12.50", "12.50", false},
+		{"+007.1", "7.1", false},
+		{"-.5", "-0.5", false},
+		{"-0.00", "0.00", false},
+		{"123456789012345678901234567890.000001", "123456789012345678901234567890.000001", false},
+		{"1e5", "", true},
+		{"NaN", "", true},
+		{"", "", true},
+	}
+	for i, test := range tests {
+		have, err := ParseDecimal(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestParseDecimal()[%d] = %v, want error %t.", i, err, test.wantErr)
+			continue
+		}
+		if err == nil && have.String() != test.want {
+			t.Errorf("TestParseDecimal()[%d] = %q, want %q.", i, have.String(), test.want)
+		}
+	}
+}
+
+// TestNewDecimal tests the NewDecimal() function.
+func TestNewDecimal(t *testing.T) {
+	tests := []struct {
+		unscaled int64
+		scale    int
+		want     string
+	}{
+		{0, 0, "0"},
+		{1250, 2, "12.50"},
+		{-5, 3, "-0.005"},
+		{42, -2, "4200"},
+	}
+	for i, test := range tests {
+		if have := NewDecimal(test.unscaled, test.scale).String(); have != test.want {
+			t.Errorf("TestNewDecimal()[%d] = %q, want %q.", i, have, test.want)
+		}
+	}
+}
+
+// TestDecimalText tests the (Decimal).MarshalText() and (*Decimal).UnmarshalText()
+// methods.
+func TestDecimalText(t *testing.T) {
+	tests := []struct {
+		input Decimal
+		want  string
+	}{
+		{Decimal{}, `"0"`},
+		{NewDecimal(-1250, 2), `"-12.50"`},
+		{NewDecimal(123456789012345678, 9), `"123456789.012345678"`},
+	}
+	for i, test := range tests {
+		data, err := json.Marshal(test.input)
+		if err != nil || string(data) != test.want {
+			t.Errorf("TestDecimalText()[%d] = %s (%v), want %s.", i, data, err, test.want)
+			continue
+		}
+		var have Decimal
+		if err := json.Unmarshal(data, &have); err != nil || have != test.input {
+			t.Errorf("TestDecimalText()[%d] = %v (%v), want Decimal %v.", i, have, err, test.input)
+		}
+	}
+	var have Decimal
+	if err := json.Unmarshal([]byte(`"1e5"`), &have); err == nil {
+		t.Errorf("TestDecimalText() = %v, want error for exponent.", have)
+	}
+}
+
+// TestDateScan tests the (*Date).Scan() method.
+func TestDateScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    Date
+		wantErr bool
+	}{
+		{time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC), Date{2020, time.February, 29}, false},
+		{[]byte("1999-12-31"), Date{1999, time.December, 31}, false},
+		{"2000-01-01", Date{2000, time.January, 1}, false},
+		{nil, Date{}, false},
+		{"01/01/2000", Date{}, true},
+		{int64(5), Date{}, true},
+	}
+	for i, test := range tests {
+		var have Date
+		err := have.Scan(test.src)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestDateScan()[%d] = %v, want error %t.", i, err, test.wantErr)
+		}
+		if have != test.want {
+			t.Errorf("TestDateScan()[%d] = %v, want Date %v.", i, have, test.want)
+		}
+	}
+}
+
+// TestTimeOfDayScan tests the (*TimeOfDay).Scan() method.
+func TestTimeOfDayScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    TimeOfDay
+		wantErr bool
+	}{
+		{time.Date(0, time.January, 1, 13, 14, 15, 500, time.UTC), TimeOfDay{13, 14, 15, 500}, false},
+		{[]byte("23:59:59"), TimeOfDay{23, 59, 59, 0}, false},
+		{"08:30:00.25", TimeOfDay{8, 30, 0, 250000000}, false},
+		{nil, TimeOfDay{}, false},
+		{"8.30pm", TimeOfDay{}, true},
+		{int64(5), TimeOfDay{}, true},
+	}
+	for i, test := range tests {
+		var have TimeOfDay
+		err := have.Scan(test.src)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestTimeOfDayScan()[%d] = %v, want error %t.", i, err, test.wantErr)
+		}
+		if have != test.want {
+			t.Errorf("TestTimeOfDayScan()[%d] = %v, want TimeOfDay %v.", i, have, test.want)
+		}
+	}
+}
+
+// TestTimeOfDayString tests the TimeOfDay.String() method.
+func TestTimeOfDayString(t *testing.T) {
+	tests := []struct {
+		input TimeOfDay
+		want  string
+	}{
+		{TimeOfDay{}, "00:00:00"},
+		{TimeOfDay{9, 5, 7, 0}, "09:05:07"},
+		{TimeOfDay{23, 59, 59, 120000000}, "23:59:59.12"},
+	}
+	for i, test := range tests {
+		if have := test.input.String(); have != test.want {
+			t.Errorf("TestTimeOfDayString()[%d] = %q, want %q.", i, have, test.want)
+		}
+	}
+}
+
+// TestScanNull tests that the custom column types scan NULL as their zero value.
+func TestScanNull(t *testing.T) {
+	decimal := NewDecimal(5, 0)
+	date := Date{2000, time.January, 1}
+	clock := TimeOfDay{12, 0, 0, 0}
+	duration := interval(time.Hour)
+	tests := []struct {
+		dest   interface{ Scan(interface{}) error }
+		isZero func() bool
+	}{
+		{&decimal, func() bool { return decimal.String() == "0" }},
+		{&date, func() bool { return date == Date{} }},
+		{&clock, func() bool { return clock == TimeOfDay{} }},
+		{&duration, func() bool { return duration == 0 }},
+	}
+	for i, test := range tests {
+		if err := test.dest.Scan(nil); err != nil {
+			t.Errorf("TestScanNull()[%d] = %v, want nil error.", i, err)
+			continue
+		}
+		if !test.isZero() {
+			t.Errorf("TestScanNull()[%d] = %v, want zero value.", i, test.dest)
+		}
+	}
+}
+
+// TestParseInterval tests the parseInterval() function.
+func TestParseInterval(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"00:00:00", 0, false},
+		{"01:02:03.5", time.Hour + 2*time.Minute + 3500*time.Millisecond, false},
+		{"-00:00:01", -time.Second, false},
+		{"3 days", 3 * day, false},
+		{"1 mon -2 days +04:00:00", 28*day + 4*time.Hour, false},
+		{"1 year", time.Duration(365.25 * float64(day)), false},
+		{"2 fortnights", 0, true},
+		{"1:2", 0, true},
+	}
+	for i, test := range tests {
+		have, err := parseInterval(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestParseInterval()[%d] = %v, want error %t.", i, err, test.wantErr)
+			continue
+		}
+		if err == nil && have != test.want {
+			t.Errorf("TestParseInterval()[%d] = %v, want %v.", i, have, test.want)
+		}
+	}
+}
+
+// TestEncodeValue tests the encodeValue() and newScanDest() functions on columns
+// whose SQL type is derived or overridden.
+func TestEncodeValue(t *testing.T) {
+	ip := net.ParseIP("10.0.0.1")
+	tests := []struct {
+		value    interface{}
+		tag      reflect.StructTag
+		want     interface{}
+		wantScan bool
+	}{
+		{time.Second, ``, formatInterval(time.Second), true},
+		{time.Second, `typ:"INTERVAL HOUR TO SECOND"`, formatInterval(time.Second), true},
+		{time.Second, `typ:"BIGINT"`, time.Second, false},
+		{ip, ``, "10.0.0.1", true},
+		{ip, `typ:"inet"`, "10.0.0.1", true},
+		{ip, `typ:"BYTEA"`, ip, false},
+	}
+	for i, test := range tests {
+		col := column{name: "col", field: reflect.StructField{Type: reflect.TypeOf(test.value), Tag: test.tag}}
+		have, err := encodeValue(col, reflect.ValueOf(test.value))
+		if err != nil || !reflect.DeepEqual(have, test.want) {
+			t.Errorf("TestEncodeValue()[%d] = %v (%v), want %v.", i, have, err, test.want)
+		}
+		if _, haveScan := newScanDest(col); haveScan != test.wantScan {
+			t.Errorf("TestEncodeValue()[%d] = %t, want custom scan destination %t.", i, haveScan, test.wantScan)
+		}
+	}
+}
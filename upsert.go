@@ -0,0 +1,278 @@
+package structql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Conflict describes how Upsert() resolves a conflict between an inserted row
+// and an existing row.  A Conflict is constructed with OnConflict() or
+// OnConstraint() and completed with DoNothing() or DoUpdate():
+//
+//  result, err := conn.Upsert("people", person, OnConflict("email").DoUpdate("name", "age"))
+//
+// Each method returns a modified copy of its receiver.
+type Conflict struct {
+	// columns are the columns of the unique index that identifies a conflict.
+	columns []string
+	// constraint is the name of the constraint that identifies a conflict.
+	constraint string
+	// sets are the SET clause entries that are applied to the existing row.
+	sets []string
+	// where is the condition that the existing row must satisfy to be updated.
+	where string
+	// nothing reports whether conflicting rows are ignored.
+	nothing bool
+}
+
+// OnConflict returns a Conflict that is identified by the unique index over
+// the given columns.  The columns may be omitted when combined with DoNothing()
+// to ignore every conflict.
+func OnConflict(columns ...string) Conflict {
+	return Conflict{columns: columns}
+}
+
+// OnConstraint returns a Conflict that is identified by the unique or
+// exclusion constraint with the given name.
+func OnConstraint(name string) Conflict {
+	return Conflict{constraint: name}
+}
+
+// DoNothing ignores the inserted row when a conflict occurs.
+func (c Conflict) DoNothing() Conflict {
+	c.nothing = true
+	c.sets = nil
+	return c
+}
+
+// DoUpdate overwrites the given columns of the existing row with the values of
+// the inserted row when a conflict occurs.
+func (c Conflict) DoUpdate(columns ...string) Conflict {
+	for _, col := range columns {
+		c = c.Set(col, "EXCLUDED."+col)
+	}
+	return c
+}
+
+// Set assigns the given SQL expression to a column of the existing row when a
+// conflict occurs.  The expression may refer to the existing row by the name
+// of the table and to the inserted row with EXCLUDED (e.g., "visits + 1" or
+// "GREATEST(score, EXCLUDED.score)").
+func (c Conflict) Set(column string, expr string) Conflict {
+	c.nothing = false
+	c.sets = append(append([]string{}, c.sets...), fmt.Sprintf("%s = %s", column, expr))
+	return c
+}
+
+// Where restricts updates to existing rows that satisfy the given condition.
+// Conflicting rows that do not satisfy the condition are left unchanged.
+func (c Conflict) Where(cond string) Conflict {
+	c.where = cond
+	return c
+}
+
+// clause returns the ON CONFLICT clause described by the Conflict receiver.
+func (c Conflict) clause() (string, error) {
+	target := ""
+	switch {
+	case c.constraint != "":
+		target = " ON CONSTRAINT " + c.constraint
+	case len(c.columns) > 0:
+		target = " (" + strings.Join(c.columns, ", ") + ")"
+	}
+
+	if c.nothing {
+		return "ON CONFLICT" + target + " DO NOTHING", nil
+	}
+	if len(c.sets) == 0 {
+		return "", fmt.Errorf("conflict resolution must either do nothing or update at least one column")
+	}
+	if target == "" {
+		return "", fmt.Errorf("conflict resolution must name the conflicting columns or constraint to update a row")
+	}
+
+	clause := "ON CONFLICT" + target + " DO UPDATE SET " + strings.Join(c.sets, ", ")
+	if c.where != "" {
+		clause += " WHERE " + c.where
+	}
+	return clause, nil
+}
+
+// UpsertAction describes the effect of an upsert on the table.
+type UpsertAction int
+
+const (
+	// Ignored indicates that the row conflicted with an existing row which was
+	// left unchanged.
+	Ignored UpsertAction = iota
+	// Inserted indicates that the row was inserted.
+	Inserted
+	// Updated indicates that the row conflicted with an existing row which was
+	// updated.
+	Updated
+)
+
+// String returns the name of the UpsertAction receiver.
+func (a UpsertAction) String() string {
+	switch a {
+	case Inserted:
+		return "inserted"
+	case Updated:
+		return "updated"
+	}
+	return "ignored"
+}
+
+// UpsertResult reports the outcome of an upsert.
+type UpsertResult struct {
+	// ID is the record ID of the inserted or updated row (see InsertObject()).
+	ID int
+	// Action is the effect of the upsert on the table.
+	Action UpsertAction
+}
+
+// Upsert inserts the given object into the specified table and resolves any
+// conflict with an existing row as described by the provided Conflict.  If the
+// object is a pointer to a structure, the structure is populated with the
+// inserted or updated row.  The BeforeInsert() hook of the object (see
+// BeforeInserter) is always invoked, and the object is validated before it is
+// inserted (see ValidationError).  The AfterInsert() hook is invoked unless the
+// row is ignored; since the row has already been written, an error from the
+// hook is returned together with the populated object and the UpsertResult.
+func (conn *Connection) Upsert(table string, object interface{}, conflict Conflict) (UpsertResult, error) {
+	// Extract the underlying type and value of the object.
+	objType := reflect.TypeOf(object)
+	objValue := reflect.ValueOf(object)
+
+	// Dereference the object so that it can be populated with the returned row.
+	var target reflect.Value
+	if objType != nil && objType.Kind() == reflect.Ptr && !objValue.IsNil() {
+		target = objValue.Elem()
+		objType = objType.Elem()
+		objValue = objValue.Elem()
+	}
+
+	// Ensure the given object is a structure.
+	if objType == nil || objType.Kind() != reflect.Struct {
+		return UpsertResult{}, fmt.Errorf("type %T is not a structure", object)
+	}
+
+	// Locate the primary key of the object.
+	keys, err := primaryKey(objType)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	// Let the object prepare itself for insertion.
+	objValue = addressable(objValue)
+	if err := runHook(objValue, beforeInsert); err != nil {
+		return UpsertResult{}, err
+	}
+
+	// Derive the ON CONFLICT clause of the INSERT statement.
+	onConflict, err := conflict.clause()
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	// Derive the columns of the object, including those of nested structures.
+	fields, untagged := getColumns(objType)
+	if err := conn.mapping.reportUntagged(objType, untagged); err != nil {
+		return UpsertResult{}, err
+	}
+
+	// Validate the columns of the object that are not generated by the database.
+	written := make([]column, 0, len(fields))
+	for _, field := range fields {
+		if !isGenerated(field) {
+			written = append(written, field)
+		}
+	}
+	if err := validateObject(objValue, written); err != nil {
+		return UpsertResult{}, err
+	}
+
+	// Construct a slice that holds the SQL column names of object fields.
+	cols := make([]string, 0, len(fields))
+	// Construct a slice that holds the PostreSQL backreferences of object fields.
+	refs := make([]string, 0, len(fields))
+	// Construct a slice that holds the values of object fields.
+	vals := make([]interface{}, 0, len(fields))
+	// Construct a slice that holds the names of the returned columns.
+	rets := make([]string, 0, len(fields))
+
+	// Append an element to each slice for every SQL field in the object.
+	for _, field := range fields {
+		rets = append(rets, field.name)
+
+		// Skip the current field if the database generates or defaults its value.
+		fieldValue := objValue.FieldByIndex(field.index)
+		if isGenerated(field) || usesDefault(field, fieldValue) {
+			continue
+		}
+
+		// Let the PostgreSQL driver handle the formatting of the value.
+		val, err := insertValue(field, fieldValue)
+		if err != nil {
+			return UpsertResult{}, err
+		}
+
+		// The PostgreSQL backreference format is the same as the regex format.
+		ref := fmt.Sprintf("$%d", len(refs)+1)
+
+		// Update the column, backreference, and value slices.
+		cols = append(cols, field.name)
+		refs = append(refs, ref)
+		vals = append(vals, val)
+	}
+
+	// Format the columns and backreferences into a VALUES clause.
+	values := "DEFAULT VALUES"
+	if len(cols) > 0 {
+		values = fmt.Sprintf("(%s) VALUES (%s)", strings.Join(cols, ", "), strings.Join(refs, ", "))
+	}
+
+	// A row that was inserted (rather than updated) has not been deleted by any
+	// transaction; see https://www.postgresql.org/docs/current/ddl-system-columns.html.
+	returning := strings.Join(append([]string{"(xmax = 0)"}, rets...), ", ")
+
+	// Scan the returned row into a copy of the object.
+	vessel := reflect.New(objType).Elem()
+	vessel.Set(objValue)
+	var inserted bool
+	dest := make([]interface{}, 0, len(fields)+1)
+	dest = append(dest, &inserted)
+	for _, field := range fields {
+		dest = append(dest, newFieldScanner(vessel, field))
+	}
+
+	// Insert the object into the specified table.  For more information, see
+	// https://www.postgresql.org/docs/current/sql-insert.html.
+	stmt := fmt.Sprintf("INSERT INTO %s %s %s RETURNING %s;", table, values, onConflict, returning)
+	row := conn.queryRow(stmt, vals...)
+	err = row.Scan(dest...)
+	if err == sql.ErrNoRows {
+		return UpsertResult{Action: Ignored}, nil
+	}
+	if err != nil {
+		return UpsertResult{}, classifyError(err)
+	}
+
+	// The row has already been written, so the object is populated and the
+	// outcome is reported even if the AfterInsert() hook fails.
+	hookErr := runHook(vessel, afterInsert)
+	if target.IsValid() {
+		target.Set(vessel)
+	}
+
+	result := UpsertResult{Action: Updated}
+	if inserted {
+		result.Action = Inserted
+	}
+	if _, ok := recordID(keys); ok {
+		result.ID = int(toInt64(vessel.FieldByIndex(keys[0].index)))
+	}
+	return result, hookErr
+}
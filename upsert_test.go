@@ -0,0 +1,113 @@
+// Package structql implements the Database structure.
+// This file contains tests for upsert.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestConflictClause tests the Conflict.clause() method.
+func TestConflictClause(t *testing.T) {
+	tests := []struct {
+		conflict   Conflict
+		wantClause string
+		wantErr    bool
+	}{
+		{
+			OnConflict().DoNothing(),
+			"ON CONFLICT DO NOTHING",
+			false,
+		}, {
+			OnConflict("email").DoNothing(),
+			"ON CONFLICT (email) DO NOTHING",
+			false,
+		}, {
+			OnConflict("email").DoUpdate("name", "age"),
+			"ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name, age = EXCLUDED.age",
+			false,
+		}, {
+			OnConstraint("people_email_key").Set("visits", "people.visits + 1").Where("people.active"),
+			"ON CONFLICT ON CONSTRAINT people_email_key DO UPDATE SET visits = people.visits + 1 WHERE people.active",
+			false,
+		}, {
+			OnConflict().DoUpdate("name"),
+			"",
+			true,
+		}, {
+			OnConflict("email"),
+			"",
+			true,
+		},
+	}
+	for i, test := range tests {
+		haveClause, err := test.conflict.clause()
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestConflictClause()[%d] = %v, want error %t.", i, err, test.wantErr)
+		}
+		if haveClause != test.wantClause {
+			t.Errorf("TestConflictClause()[%d] = %q, want %q.", i, haveClause, test.wantClause)
+		}
+	}
+}
+
+// TestUpsert tests the (*Connection).Upsert() method.
+func TestUpsert(t *testing.T) {
+	type Person struct {
+		ID    int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Email string `sql:"email" opt:"UNIQUE"`
+		Name  string `sql:"name"`
+		Age   int32  `sql:"age"`
+	}
+
+	tests := []struct {
+		person     Person
+		conflict   Conflict
+		wantResult UpsertResult
+		wantPerson Person
+	}{
+		{
+			Person{Email: "ann@example.com", Name: "Ann", Age: 30},
+			OnConflict("email").DoUpdate("name", "age"),
+			UpsertResult{1, Inserted},
+			Person{1, "ann@example.com", "Ann", 30},
+		}, {
+			Person{Email: "ann@example.com", Name: "Anne", Age: 31},
+			OnConflict("email").DoUpdate("name", "age"),
+			UpsertResult{1, Updated},
+			Person{1, "ann@example.com", "Anne", 31},
+		}, {
+			Person{Email: "ann@example.com", Name: "Annie", Age: 29},
+			OnConflict("email").DoUpdate("name", "age").Where("EXCLUDED.age > People.age"),
+			UpsertResult{0, Ignored},
+			Person{1, "ann@example.com", "Anne", 31},
+		}, {
+			Person{Email: "ann@example.com", Name: "Ann", Age: 40},
+			OnConflict().DoNothing(),
+			UpsertResult{0, Ignored},
+			Person{1, "ann@example.com", "Anne", 31},
+		},
+	}
+
+	conn := createTableUnsafe("People", Person{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	for i, test := range tests {
+		haveResult, err := conn.Upsert("People", test.person, test.conflict)
+		if err != nil {
+			t.Errorf("TestUpsert()[%d] - failed to upsert Person: %v.", i, err)
+			continue
+		}
+		if haveResult != test.wantResult {
+			t.Errorf("TestUpsert()[%d] = %v, want result %v.", i, haveResult, test.wantResult)
+		}
+
+		people, err := conn.SelectFrom(Person{}, "People")
+		if err != nil {
+			t.Errorf("TestUpsert()[%d] - failed to select People: %v.", i, err)
+		} else if len(people) != 1 || !reflect.DeepEqual(people[0], test.wantPerson) {
+			t.Errorf("TestUpsert()[%d] = %v, want Person %v.", i, people, test.wantPerson)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package structql
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes a structure field whose value violates one of the rules
+// in its "validate" tag.
+type FieldError struct {
+	// Field is the name of the structure field, including any enclosing fields.
+	Field string
+	// Column is the name of the SQL column of the field.
+	Column string
+	// Rule is the violated rule (e.g., "max=120").
+	Rule string
+	// Message describes the violation.
+	Message string
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return fmt.Sprintf("field %s (column %q) %s", e.Field, e.Column, e.Message)
+}
+
+// ValidationError is returned by the write operations of a Connection when the
+// fields of an object violate the rules in their "validate" tags:
+//
+//  type Person struct {
+//    ID    int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+//    Name  string `sql:"name" validate:"required,max=64"`
+//    Age   int32  `sql:"age" validate:"min=0,max=150"`
+//    Role  string `sql:"role" validate:"oneof=admin user guest"`
+//    Email string `sql:"email" validate:"regex=^[^@]+@[^@]+$"`
+//  }
+//
+// The supported rules are:
+//   - required: the value is not the zero value of its type.
+//   - min=N and max=N: the value of a number, or the length of a string, slice,
+//     or map, lies within the bound.
+//   - len=N: the length of a string, slice, or map is exactly N.
+//   - oneof=A B C: the value of a string or integer is one of the space-separated
+//     values.
+//   - regex=R: a string matches the regular expression R.  Since R may contain
+//     commas, this rule must come last.
+//
+// Every violated rule is reported, not just the first.
+type ValidationError struct {
+	// Fields holds a FieldError for each violated rule.
+	Fields []FieldError
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		msgs[i] = field.Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// rule is a single rule of a "validate" tag.
+type rule struct {
+	// name is the name of the rule (e.g., "max").
+	name string
+	// param is the parameter of the rule (e.g., "120"), if any.
+	param string
+	// re is the compiled regular expression of a "regex" rule.
+	re *regexp.Regexp
+}
+
+// String returns the rule receiver as it appears in a "validate" tag.
+func (r rule) String() string {
+	if r.param == "" {
+		return r.name
+	}
+	return r.name + "=" + r.param
+}
+
+// parseRules parses the "validate" tag of the given column into its rules.
+func parseRules(col column) ([]rule, error) {
+	tag := col.field.Tag.Get("validate")
+	if tag == "" {
+		return nil, nil
+	}
+
+	rules := []rule{}
+	parts := strings.Split(tag, ",")
+	for i := 0; i < len(parts); i++ {
+		part := strings.TrimSpace(parts[i])
+		if part == "" {
+			continue
+		}
+		r := rule{name: part}
+		if eq := strings.IndexByte(part, '='); eq >= 0 {
+			r = rule{name: part[:eq], param: part[eq+1:]}
+		}
+		var err error
+
+		switch r.name {
+		case "required":
+		case "min", "max", "len":
+			if _, err := strconv.ParseFloat(r.param, 64); err != nil {
+				return nil, fmt.Errorf("rule %q of column %q requires a number", r.name, col.name)
+			}
+		case "oneof":
+			if len(strings.Fields(r.param)) == 0 {
+				return nil, fmt.Errorf("rule %q of column %q requires at least one value", r.name, col.name)
+			}
+		case "regex":
+			// The regular expression extends to the end of the tag.
+			r.param = strings.Join(append([]string{r.param}, parts[i+1:]...), ",")
+			i = len(parts)
+			if r.re, err = regexp.Compile(r.param); err != nil {
+				return nil, fmt.Errorf("rule %q of column %q has an invalid regular expression: %w", r.name, col.name, err)
+			}
+		default:
+			return nil, fmt.Errorf("column %q has unknown validation rule %q", col.name, r.name)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// validateObject checks the given columns of the object against the rules in
+// their "validate" tags.  A *ValidationError is returned if any rule is
+// violated.
+func validateObject(objVal reflect.Value, cols []column) error {
+	info := structInfoOf(objVal.Type())
+	violations := []FieldError{}
+	for _, col := range cols {
+		if err := info.ruleErrs[col.name]; err != nil {
+			return err
+		}
+		rules := info.rules[col.name]
+		val := objVal.FieldByIndex(col.index)
+		for _, r := range rules {
+			msg, err := checkRule(r, val)
+			if err != nil {
+				return fmt.Errorf("failed to validate column %q: %w", col.name, err)
+			}
+			if msg != "" {
+				violations = append(violations, FieldError{fieldPath(objVal.Type(), col.index), col.name, r.String(), msg})
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return &ValidationError{violations}
+	}
+	return nil
+}
+
+// checkRule checks the given value against the provided rule and returns a
+// description of the violation, if any.
+func checkRule(r rule, val reflect.Value) (string, error) {
+	switch r.name {
+	case "required":
+		if isZero(val) {
+			return "is required", nil
+		}
+	case "min", "max", "len":
+		bound, _ := strconv.ParseFloat(r.param, 64)
+		n, isLength, err := magnitude(val)
+		if err != nil {
+			return "", fmt.Errorf("rule %q: %w", r.name, err)
+		}
+		what := "must be"
+		if isLength {
+			what = "must have length"
+		}
+		switch {
+		case r.name == "min" && n < bound:
+			return fmt.Sprintf("%s at least %s", what, r.param), nil
+		case r.name == "max" && n > bound:
+			return fmt.Sprintf("%s at most %s", what, r.param), nil
+		case r.name == "len" && !isLength:
+			return "", fmt.Errorf("rule %q does not apply to type %s", r.name, val.Type())
+		case r.name == "len" && n != bound:
+			return fmt.Sprintf("%s %s", what, r.param), nil
+		}
+	case "oneof":
+		var s string
+		switch {
+		case val.Kind() == reflect.String:
+			s = val.String()
+		case isInteger(val.Type()):
+			s = strconv.FormatInt(toInt64(val), 10)
+		default:
+			return "", fmt.Errorf("rule %q does not apply to type %s", r.name, val.Type())
+		}
+		if !containsString(strings.Fields(r.param), s) {
+			return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(r.param), ", ")), nil
+		}
+	case "regex":
+		if val.Kind() != reflect.String {
+			return "", fmt.Errorf("rule %q does not apply to type %s", r.name, val.Type())
+		}
+		if !r.re.MatchString(val.String()) {
+			return fmt.Sprintf("must match %s", r.param), nil
+		}
+	}
+	return "", nil
+}
+
+// magnitude returns the value of the given number or the length of the given
+// string, slice, or map.  The boolean result reports whether a length was
+// returned.
+func magnitude(val reflect.Value) (float64, bool, error) {
+	switch val.Kind() {
+	case reflect.String:
+		return float64(len([]rune(val.String()))), true, nil
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(val.Len()), true, nil
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), false, nil
+	}
+	if isInteger(val.Type()) {
+		return float64(toInt64(val)), false, nil
+	}
+	if d, ok := val.Interface().(Decimal); ok {
+		return d.Float64(), false, nil
+	}
+	return 0, false, fmt.Errorf("type %s has neither a value nor a length", val.Type())
+}
+
+// fieldPath returns the dotted name of the field with the given index sequence
+// in the provided structure type (e.g., "Addr.City").
+func fieldPath(typ reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i, n := range index {
+		field := typ.Field(n)
+		names[i] = field.Name
+		typ = field.Type
+	}
+	return strings.Join(names, ".")
+}
+
+// checkConstraint returns a CHECK constraint that mirrors the rules in the
+// "validate" tag of the given column, which is requested by the "check" option
+// of its "sql" tag:
+//
+//  Age int32 `sql:"age,check" validate:"min=0,max=150"`
+//
+// Rules that cannot be expressed in SQL are omitted.  Note that the database
+// evaluates regular expressions with its own dialect.
+func (conn *Connection) checkConstraint(col column) (string, error) {
+	rules, err := parseRules(col)
+	if err != nil {
+		return "", err
+	}
+
+	typ := col.field.Type
+	isLength := typ.Kind() == reflect.String
+	isNumber := isInteger(typ) || typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64 || typ == reflect.TypeOf(Decimal{})
+	length := fmt.Sprintf("char_length(%s)", col.name)
+
+	conds := []string{}
+	for _, r := range rules {
+		switch {
+		case r.name == "required" && isLength:
+			conds = append(conds, fmt.Sprintf("%s <> ''", col.name))
+		case r.name == "required" && isNumber:
+			conds = append(conds, fmt.Sprintf("%s <> 0", col.name))
+		case r.name == "min" && isLength:
+			conds = append(conds, fmt.Sprintf("%s >= %s", length, r.param))
+		case r.name == "min" && isNumber:
+			conds = append(conds, fmt.Sprintf("%s >= %s", col.name, r.param))
+		case r.name == "max" && isLength:
+			conds = append(conds, fmt.Sprintf("%s <= %s", length, r.param))
+		case r.name == "max" && isNumber:
+			conds = append(conds, fmt.Sprintf("%s <= %s", col.name, r.param))
+		case r.name == "len" && isLength:
+			conds = append(conds, fmt.Sprintf("%s = %s", length, r.param))
+		case r.name == "oneof" && isLength:
+			conds = append(conds, fmt.Sprintf("%s IN (%s)", col.name, quoteLiterals(strings.Fields(r.param))))
+		case r.name == "oneof" && isInteger(typ):
+			conds = append(conds, fmt.Sprintf("%s IN (%s)", col.name, strings.Join(strings.Fields(r.param), ", ")))
+		case r.name == "regex" && isLength && conn.driver == MySQL:
+			conds = append(conds, fmt.Sprintf("%s REGEXP %s", col.name, quoteLiteral(r.param)))
+		case r.name == "regex" && isLength:
+			conds = append(conds, fmt.Sprintf("%s ~ %s", col.name, quoteLiteral(r.param)))
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("CHECK (%s)", strings.Join(conds, " AND ")), nil
+}
@@ -0,0 +1,137 @@
+// Package structql implements the Database structure.
+// This file contains tests for validate.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+)
+
+// validPerson is a person whose fields are validated before they are written.
+type validPerson struct {
+	ID    int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+	Name  string `sql:"name,check" validate:"required,max=8"`
+	Age   int32  `sql:"age,check" validate:"min=0,max=150"`
+	Role  string `sql:"role,check" validate:"oneof=admin user"`
+	Email string `sql:"email,check" validate:"regex=^[a-z]{1,8}@example[.]com$"`
+	Code  string `sql:"code" validate:"len=2"`
+}
+
+// TestParseRules tests the parseRules() function.
+func TestParseRules(t *testing.T) {
+	type Rules struct {
+		A string `validate:"required,min=1"`
+		B string `validate:"oneof=x y,regex=^a{1,2}$"`
+		C string `validate:"min=one"`
+		D string `validate:"unique"`
+		E string `validate:"regex=("`
+		F string
+	}
+
+	typ := reflect.TypeOf(Rules{})
+	tests := []struct {
+		field   string
+		want    []string
+		wantErr bool
+	}{
+		{"A", []string{"required", "min=1"}, false},
+		{"B", []string{"oneof=x y", "regex=^a{1,2}$"}, false},
+		{"C", nil, true},
+		{"D", nil, true},
+		{"E", nil, true},
+		{"F", nil, false},
+	}
+	for i, test := range tests {
+		field, _ := typ.FieldByName(test.field)
+		rules, err := parseRules(column{name: test.field, field: field})
+		var have []string
+		for _, r := range rules {
+			have = append(have, r.String())
+		}
+		if !reflect.DeepEqual(have, test.want) || (err != nil) != test.wantErr {
+			t.Errorf("TestParseRules()[%d] = (%v, %v), want (%v, error = %t).", i, have, err, test.want, test.wantErr)
+		}
+	}
+}
+
+// TestValidateObject tests the validateObject() function.
+func TestValidateObject(t *testing.T) {
+	cols, _ := getColumns(reflect.TypeOf(validPerson{}))
+	valid := validPerson{Name: "Bob", Age: 30, Role: "user", Email: "bob@example.com", Code: "ab"}
+
+	tests := []struct {
+		person    validPerson
+		wantRules []string
+	}{
+		{valid, nil},
+		{validPerson{Name: "Bartholomew", Age: -1, Role: "root", Email: "bob", Code: "abc"}, []string{"max=8", "min=0", "oneof=admin user", "regex=^[a-z]{1,8}@example[.]com$", "len=2"}},
+		{validPerson{Age: 151, Role: "admin", Email: "a@example.com", Code: "ab"}, []string{"required", "max=150"}},
+	}
+	for i, test := range tests {
+		err := validateObject(reflect.ValueOf(test.person), cols)
+		var haveRules []string
+		if verr, ok := err.(*ValidationError); ok {
+			for _, field := range verr.Fields {
+				haveRules = append(haveRules, field.Rule)
+			}
+		} else if err != nil {
+			t.Errorf("TestValidateObject()[%d] = %v, want *ValidationError.", i, err)
+		}
+		if !reflect.DeepEqual(haveRules, test.wantRules) {
+			t.Errorf("TestValidateObject()[%d] = %v, want violated rules %v.", i, haveRules, test.wantRules)
+		}
+	}
+}
+
+// TestCheckConstraint tests the (*Connection).checkConstraint() method.
+func TestCheckConstraint(t *testing.T) {
+	cols, _ := getColumns(reflect.TypeOf(validPerson{}))
+	tests := []struct {
+		driver Driver
+		col    column
+		want   string
+	}{
+		{Postgres, cols[1], "CHECK (name <> '' AND char_length(name) <= 8)"},
+		{Postgres, cols[2], "CHECK (age >= 0 AND age <= 150)"},
+		{Postgres, cols[3], "CHECK (role IN ('admin', 'user'))"},
+		{Postgres, cols[4], "CHECK (email ~ '^[a-z]{1,8}@example[.]com$')"},
+		{MySQL, cols[4], "CHECK (email REGEXP '^[a-z]{1,8}@example[.]com$')"},
+		{Postgres, cols[0], ""},
+	}
+	for i, test := range tests {
+		conn := &Connection{driver: test.driver}
+		have, err := conn.checkConstraint(test.col)
+		if have != test.want || err != nil {
+			t.Errorf("TestCheckConstraint()[%d] = (%q, %v), want %q.", i, have, err, test.want)
+		}
+	}
+}
+
+// TestValidatedObject tests that invalid objects are not written.
+func TestValidatedObject(t *testing.T) {
+	conn := createTableUnsafe("People", validPerson{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	person := validPerson{Name: "Bob", Age: 30, Role: "user", Email: "bob@example.com", Code: "ab"}
+	if _, err := conn.InsertObject("People", &person); err != nil {
+		t.Fatalf("Failed to insert Person: %v.", err)
+	}
+
+	invalid := person
+	invalid.Age = 200
+	if err := conn.UpdateObject("People", invalid); err == nil {
+		t.Errorf("TestValidatedObject() - updated an invalid Person.")
+	}
+	if _, err := conn.InsertObjects("People", []validPerson{person, invalid}); err == nil {
+		t.Errorf("TestValidatedObject() - inserted an invalid Person.")
+	}
+
+	// Verify that the CHECK constraints reject invalid rows as well.
+	if _, err := conn.UpdateWhere("People", map[string]interface{}{"age": 200}, "id = $1", person.ID); err == nil {
+		t.Errorf("TestValidatedObject() - CHECK constraint accepted an invalid age.")
+	}
+	if n, err := conn.CountRows("People"); err != nil || n != 1 {
+		t.Errorf("TestValidatedObject() = %d (%v), want 1 row.", n, err)
+	}
+}
@@ -0,0 +1,56 @@
+package structql
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ErrStaleObject is returned by UpdateObject() and UpdateFields() when the
+// version of an object no longer matches the version of its row, which means
+// that the row was modified (or deleted) since the object was loaded.  A column
+// is used as the version of an object when its "sql" tag carries the "version"
+// option:
+//
+//  type Account struct {
+//    ID      int32 `sql:"id" opt:"PRIMARY KEY"`
+//    Balance int64 `sql:"balance"`
+//    Version int64 `sql:"version,version"`
+//  }
+//
+// Objects with a version column are only updated if their version matches the
+// version of their row, which is incremented by every update.
+type ErrStaleObject struct {
+	// Table is the name of the table holding the row.
+	Table string
+	// Version is the version of the object that failed to update.
+	Version int64
+}
+
+// Error implements the error interface.
+func (e *ErrStaleObject) Error() string {
+	return fmt.Sprintf("object with version %d is stale or missing from table %q", e.Version, e.Table)
+}
+
+// versionColumn returns the column of the given columns that holds the version
+// of an object.  The boolean result reports whether such a column exists.
+func versionColumn(cols []column) (column, bool) {
+	for _, col := range cols {
+		if col.opts.has("version") {
+			return col, true
+		}
+	}
+	return column{}, false
+}
+
+// insertValue returns the value of the given column for a newly inserted row.
+// The version of an object starts at 1 unless it is set explicitly, and the
+// soft-delete column of an object is NULL unless the object has been deleted.
+func insertValue(col column, val reflect.Value) (interface{}, error) {
+	if col.opts.has("version") && isInteger(val.Type()) && isZero(val) {
+		return int64(1), nil
+	}
+	if col.opts.has("softdelete") && val.Type() == timeType && isZero(val) {
+		return nil, nil
+	}
+	return encodeValue(col, val)
+}
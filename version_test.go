@@ -0,0 +1,82 @@
+// Package structql implements the Database structure.
+// This file contains tests for version.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestInsertValue tests the insertValue() function.
+func TestInsertValue(t *testing.T) {
+	type Account struct {
+		ID      int32 `sql:"id"`
+		Version int64 `sql:"version,version"`
+	}
+
+	cols, _ := getColumns(reflect.TypeOf(Account{}))
+	tests := []struct {
+		account Account
+		wantID  interface{}
+		wantVer interface{}
+	}{
+		{Account{0, 0}, int32(0), int64(1)},
+		{Account{5, 3}, int32(5), int64(3)},
+	}
+	for i, test := range tests {
+		value := reflect.ValueOf(test.account)
+		haveID, _ := insertValue(cols[0], value.FieldByIndex(cols[0].index))
+		haveVer, _ := insertValue(cols[1], value.FieldByIndex(cols[1].index))
+		if haveID != test.wantID || haveVer != test.wantVer {
+			t.Errorf("TestInsertValue()[%d] = (%v, %v), want (%v, %v).", i, haveID, haveVer, test.wantID, test.wantVer)
+		}
+	}
+}
+
+// TestVersionedUpdate tests the optimistic concurrency control of the
+// (*Connection).UpdateObject() method.
+func TestVersionedUpdate(t *testing.T) {
+	type Account struct {
+		ID      int32 `sql:"id" opt:"PRIMARY KEY"`
+		Balance int64 `sql:"balance"`
+		Version int64 `sql:"version,version"`
+	}
+
+	conn := createTableUnsafe("Accounts", Account{})
+	defer conn.Close()
+	defer conn.DropTable("Accounts")
+
+	account := Account{ID: 1, Balance: 100}
+	if _, err := conn.InsertObject("Accounts", &account); err != nil {
+		t.Fatalf("Failed to insert Account: %v.", err)
+	}
+	if account.Version != 1 {
+		t.Fatalf("TestVersionedUpdate() = %d, want initial version 1.", account.Version)
+	}
+
+	// Update the account through two copies; the second update must fail.
+	first, second := account, account
+	first.Balance = 150
+	if err := conn.UpdateObject("Accounts", &first); err != nil {
+		t.Fatalf("TestVersionedUpdate() - failed to update Account: %v.", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("TestVersionedUpdate() = %d, want version 2.", first.Version)
+	}
+
+	second.Balance = 50
+	err := conn.UpdateObject("Accounts", &second)
+	if stale, ok := err.(*ErrStaleObject); !ok || stale.Version != 1 {
+		t.Errorf("TestVersionedUpdate() = %v, want *ErrStaleObject for version 1.", err)
+	}
+
+	// Verify that a missing row is reported.
+	if err := conn.UpdateObject("Accounts", Account{ID: 2, Version: 1}); err == nil {
+		t.Errorf("TestVersionedUpdate() - updated a missing Account.")
+	}
+
+	people, err := conn.SelectFrom(Account{}, "Accounts")
+	if err != nil || len(people) != 1 || !reflect.DeepEqual(people[0], first) {
+		t.Errorf("TestVersionedUpdate() = %v (%v), want Account %v.", people, err, first)
+	}
+}
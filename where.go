@@ -0,0 +1,210 @@
+package structql
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// identifierPattern matches a plain (unquoted and unqualified) SQL identifier.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// UpdateWhere assigns the given values to every row of the specified table that
+// satisfies the provided condition and returns the number of affected rows.
+// The assignments are either a map from column names to values or a structure
+// whose tagged non-key columns are all assigned.  As in UpdateObject(), a
+// structure never assigns its soft-delete column and increments the version
+// column of each row instead of assigning it.  The keys of a map must be plain
+// column names, and its values are encoded according to their Go types (e.g.,
+// a time.Duration is written as an INTERVAL, so a duration that is stored in an
+// integer column must be given as an int64).  Unlike SelectFromWhere(), the
+// condition is parameterized: the arguments are referenced as $1, $2, etc.
+//
+//  n, err := conn.UpdateWhere("jobs", map[string]interface{}{"status": "stale"}, "updated_at < $1", cutoff)
+func (conn *Connection) UpdateWhere(table string, assignments interface{}, cond string, args ...interface{}) (int64, error) {
+	stmt, vals, err := updateWhereStmt(table, assignments, cond, args, nil, "")
+	if err != nil {
+		return 0, err
+	}
+	result, err := conn.exec(stmt, vals...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// UpdateWhereReturning behaves like UpdateWhere() but returns the updated rows
+// as a slice of structures with the type of the given object.  The keys of a
+// map of assignments must be columns of the object, and their values are
+// encoded according to the tags of those columns (e.g., enumerations are
+// validated).
+func (conn *Connection) UpdateWhereReturning(object interface{}, table string, assignments interface{}, cond string, args ...interface{}) ([]interface{}, error) {
+	returning, err := returningList(object)
+	if err != nil {
+		return nil, err
+	}
+	stmt, vals, err := updateWhereStmt(table, assignments, cond, args, reflect.TypeOf(object), returning)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.query(stmt, vals...)
+	if err != nil {
+		return nil, err
+	}
+	return parseResponse(rows, object, conn.mapping)
+}
+
+// DeleteWhere deletes every row of the specified table that satisfies the
+// provided condition and returns the number of deleted rows.  As with
+// UpdateWhere(), the condition is parameterized.
+func (conn *Connection) DeleteWhere(table string, cond string, args ...interface{}) (int64, error) {
+	if err := checkCondition(cond); err != nil {
+		return 0, err
+	}
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE %s;", table, cond)
+	result, err := conn.exec(stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteWhereReturning behaves like DeleteWhere() but returns the deleted rows
+// as a slice of structures with the type of the given object.
+func (conn *Connection) DeleteWhereReturning(object interface{}, table string, cond string, args ...interface{}) ([]interface{}, error) {
+	if err := checkCondition(cond); err != nil {
+		return nil, err
+	}
+	returning, err := returningList(object)
+	if err != nil {
+		return nil, err
+	}
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE %s RETURNING %s;", table, cond, returning)
+	rows, err := conn.query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseResponse(rows, object, conn.mapping)
+}
+
+// checkCondition guards against accidentally updating or deleting every row of
+// a table with an empty condition.
+func checkCondition(cond string) error {
+	if strings.TrimSpace(cond) == "" {
+		return fmt.Errorf("condition must not be empty; use \"TRUE\" to affect every row")
+	}
+	return nil
+}
+
+// returningList returns the comma-separated list of the columns of the given
+// object for use in a RETURNING clause.
+func returningList(object interface{}) (string, error) {
+	template := reflect.TypeOf(object)
+	if template == nil || template.Kind() != reflect.Struct {
+		return "", fmt.Errorf("type %T is not a structure", object)
+	}
+	fields, _ := getColumns(template)
+	cols := make([]string, 0, len(fields))
+	for _, field := range fields {
+		cols = append(cols, field.name)
+	}
+	return strings.Join(cols, ", "), nil
+}
+
+// updateWhereStmt constructs an UPDATE statement that applies the given
+// assignments to the rows of a table that satisfy the provided condition.  The
+// values of the assignments are backreferenced after the condition arguments.
+// If the template is not nil, the keys of a map of assignments must be columns
+// of the template structure.  If the returning list is not empty, it is added
+// as a RETURNING clause.
+func updateWhereStmt(table string, assignments interface{}, cond string, args []interface{}, template reflect.Type, returning string) (string, []interface{}, error) {
+	if err := checkCondition(cond); err != nil {
+		return "", nil, err
+	}
+
+	vals := append([]interface{}{}, args...)
+	sets := []string{}
+	assign := func(col string, val interface{}) {
+		vals = append(vals, val)
+		sets = append(sets, fmt.Sprintf("%s = $%d", col, len(vals)))
+	}
+
+	value := reflect.ValueOf(assignments)
+	switch {
+	case value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String:
+		// Assign the columns in a deterministic order.
+		cols := make([]string, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			cols = append(cols, key.String())
+		}
+		sort.Strings(cols)
+
+		var known map[string]column
+		if template != nil {
+			known = structInfoOf(template).byName
+		}
+		for _, name := range cols {
+			if !identifierPattern.MatchString(name) {
+				return "", nil, fmt.Errorf("column %q is not a plain SQL identifier", name)
+			}
+			col, ok := known[name]
+			if template != nil && !ok {
+				return "", nil, fmt.Errorf("no field in structure %s is tagged with SQL column %q", template, name)
+			}
+
+			// Encode the value as though it were a field of its own type.
+			entry := value.MapIndex(reflect.ValueOf(name).Convert(value.Type().Key()))
+			if entry.Kind() == reflect.Interface {
+				entry = entry.Elem()
+			}
+			if !entry.IsValid() {
+				assign(name, nil)
+				continue
+			}
+			if !ok {
+				col = column{name: name, field: reflect.StructField{Type: entry.Type()}}
+			}
+			val, err := encodeValue(col, entry)
+			if err != nil {
+				return "", nil, err
+			}
+			assign(name, val)
+		}
+	case value.Kind() == reflect.Struct:
+		keys, err := primaryKey(value.Type())
+		if err != nil {
+			return "", nil, err
+		}
+		fields, _ := getColumns(value.Type())
+		for _, field := range fields {
+			// As in UpdateObject(), the soft-delete column is only written by
+			// DeleteObject() and the version column is incremented below.
+			if isKey(keys, field) || isGenerated(field) || field.opts.has("softdelete") || field.opts.has("version") {
+				continue
+			}
+			val, err := encodeValue(field, value.FieldByIndex(field.index))
+			if err != nil {
+				return "", nil, err
+			}
+			assign(field.name, val)
+		}
+		sets = append(sets, autoUpdates(fields)...)
+		if version, ok := versionColumn(fields); ok {
+			sets = append(sets, fmt.Sprintf("%s = %s + 1", version.name, version.name))
+		}
+	default:
+		return "", nil, fmt.Errorf("type %T is neither a map of columns nor a structure", assignments)
+	}
+
+	if len(sets) == 0 {
+		return "", nil, fmt.Errorf("no columns were given to update")
+	}
+
+	stmt := fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(sets, ", "), cond)
+	if returning != "" {
+		stmt += " RETURNING " + returning
+	}
+	return stmt + ";", vals, nil
+}
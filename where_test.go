@@ -0,0 +1,194 @@
+// Package structql implements the Database structure.
+// This file contains tests for where.go.
+package structql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestUpdateWhereStmt tests the updateWhereStmt() function.
+func TestUpdateWhereStmt(t *testing.T) {
+	type Person struct {
+		ID   int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Name string `sql:"name"`
+		Age  int32  `sql:"age"`
+	}
+
+	type Account struct {
+		ID      int32     `sql:"id" opt:"PRIMARY KEY"`
+		Name    string    `sql:"name"`
+		Deleted time.Time `sql:"deleted_at,softdelete"`
+	}
+	type Ledger struct {
+		ID      int32 `sql:"id" opt:"PRIMARY KEY"`
+		Balance int64 `sql:"balance"`
+		Version int64 `sql:"version,version"`
+	}
+
+	tests := []struct {
+		assignments interface{}
+		cond        string
+		args        []interface{}
+		returning   string
+		wantStmt    string
+		wantVals    []interface{}
+		wantErr     bool
+	}{
+		{
+			map[string]interface{}{"name": "Ann", "age": 30},
+			"id = $1",
+			[]interface{}{7},
+			"",
+			"UPDATE people SET age = $2, name = $3 WHERE id = $1;",
+			[]interface{}{7, 30, "Ann"},
+			false,
+		}, {
+			Person{ID: 1, Name: "Bob", Age: 40},
+			"age > $1 AND name <> $2",
+			[]interface{}{18, "Bob"},
+			"id, name, age",
+			"UPDATE people SET name = $3, age = $4 WHERE age > $1 AND name <> $2 RETURNING id, name, age;",
+			[]interface{}{18, "Bob", "Bob", int32(40)},
+			false,
+		}, {
+			Account{ID: 1, Name: "Ann"},
+			"id = $1",
+			[]interface{}{1},
+			"",
+			"UPDATE people SET name = $2 WHERE id = $1;",
+			[]interface{}{1, "Ann"},
+			false,
+		}, {
+			Ledger{ID: 1, Balance: 50, Version: 3},
+			"balance < $1",
+			[]interface{}{100},
+			"",
+			"UPDATE people SET balance = $2, version = version + 1 WHERE balance < $1;",
+			[]interface{}{100, int64(50)},
+			false,
+		}, {
+			map[string]interface{}{"name": "Ann"},
+			" ",
+			nil,
+			"",
+			"",
+			nil,
+			true,
+		}, {
+			map[string]interface{}{},
+			"TRUE",
+			nil,
+			"",
+			"",
+			nil,
+			true,
+		}, {
+			[]string{"name"},
+			"TRUE",
+			nil,
+			"",
+			"",
+			nil,
+			true,
+		},
+	}
+	for i, test := range tests {
+		haveStmt, haveVals, err := updateWhereStmt("people", test.assignments, test.cond, test.args, nil, test.returning)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestUpdateWhereStmt()[%d] = %v, want error %t.", i, err, test.wantErr)
+			continue
+		}
+		if haveStmt != test.wantStmt {
+			t.Errorf("TestUpdateWhereStmt()[%d] = %q, want statement %q.", i, haveStmt, test.wantStmt)
+		}
+		if !reflect.DeepEqual(haveVals, test.wantVals) {
+			t.Errorf("TestUpdateWhereStmt()[%d] = %v, want values %v.", i, haveVals, test.wantVals)
+		}
+	}
+}
+
+// TestUpdateWhereAssignments tests that updateWhereStmt() validates the columns
+// of a map of assignments and encodes their values.
+func TestUpdateWhereAssignments(t *testing.T) {
+	type Job struct {
+		ID     int32         `sql:"id" opt:"PRIMARY KEY"`
+		Status string        `sql:"status" enum:"where_job_status"`
+		Limit  time.Duration `sql:"time_limit"`
+	}
+	if err := RegisterEnum("where_job_status", []string{"pending", "running"}); err != nil {
+		t.Fatalf("Failed to register enum: %v.", err)
+	}
+
+	tests := []struct {
+		assignments interface{}
+		object      interface{}
+		wantVals    []interface{}
+		wantErr     bool
+	}{
+		{map[string]interface{}{"time_limit": time.Minute}, nil, []interface{}{formatInterval(time.Minute)}, false},
+		{map[string]interface{}{"name": nil}, nil, []interface{}{nil}, false},
+		{map[string]string{"name": "Ann"}, nil, []interface{}{"Ann"}, false},
+		{map[string]interface{}{"name = 'x', admin": true}, nil, nil, true},
+		{map[string]interface{}{"name; DROP TABLE people; --": "x"}, nil, nil, true},
+		{map[string]interface{}{"status": "running"}, Job{}, []interface{}{"running"}, false},
+		{map[string]interface{}{"status": "bogus"}, Job{}, nil, true},
+		{map[string]interface{}{"name": "Ann"}, Job{}, nil, true},
+	}
+	for i, test := range tests {
+		_, haveVals, err := updateWhereStmt("people", test.assignments, "TRUE", nil, reflect.TypeOf(test.object), "")
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestUpdateWhereAssignments()[%d] = %v, want error %t.", i, err, test.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(haveVals, test.wantVals) {
+			t.Errorf("TestUpdateWhereAssignments()[%d] = %v, want values %v.", i, haveVals, test.wantVals)
+		}
+	}
+}
+
+// TestUpdateDeleteWhere tests the set-based update and delete methods of the
+// Connection type.
+func TestUpdateDeleteWhere(t *testing.T) {
+	type Person struct {
+		ID   int32  `sql:"id" typ:"SERIAL" opt:"PRIMARY KEY"`
+		Name string `sql:"name"`
+		Age  int32  `sql:"age"`
+	}
+
+	conn := createTableUnsafe("People", Person{})
+	defer conn.Close()
+	defer conn.DropTable("People")
+
+	people := []Person{{1, "Ann", 20}, {2, "Bob", 30}, {3, "Cat", 40}}
+	if _, err := conn.InsertObjects("People", people); err != nil {
+		t.Fatalf("Failed to insert People: %v.", err)
+	}
+
+	n, err := conn.UpdateWhere("People", map[string]interface{}{"age": 50}, "age >= $1", 30)
+	if err != nil || n != 2 {
+		t.Errorf("TestUpdateDeleteWhere() = %d (%v), want 2 updated rows.", n, err)
+	}
+
+	updated, err := conn.UpdateWhereReturning(Person{}, "People", map[string]interface{}{"name": "Ada"}, "id = $1", 1)
+	if err != nil {
+		t.Errorf("TestUpdateDeleteWhere() - failed to update rows: %v.", err)
+	} else if want := []interface{}{Person{1, "Ada", 20}}; !reflect.DeepEqual(updated, want) {
+		t.Errorf("TestUpdateDeleteWhere() = %v, want updated rows %v.", updated, want)
+	}
+
+	deleted, err := conn.DeleteWhereReturning(Person{}, "People", "name = $1", "Bob")
+	if err != nil {
+		t.Errorf("TestUpdateDeleteWhere() - failed to delete rows: %v.", err)
+	} else if want := []interface{}{Person{2, "Bob", 50}}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("TestUpdateDeleteWhere() = %v, want deleted rows %v.", deleted, want)
+	}
+
+	if n, err := conn.DeleteWhere("People", "TRUE"); err != nil || n != 2 {
+		t.Errorf("TestUpdateDeleteWhere() = %d (%v), want 2 deleted rows.", n, err)
+	}
+	if _, err := conn.DeleteWhere("People", ""); err == nil {
+		t.Errorf("TestUpdateDeleteWhere() - deleted rows without a condition.")
+	}
+}